@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+var adminToken string
+
+var errServerNotFound = errors.New("server not found")
+var errServerExists = errors.New("server already exists")
+
+type reorderRequest struct {
+	IDs []string `json:"ids"`
+}
+
+func adminAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="proxyhub-admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeRegistryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errServerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errServerExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	}
+}
+
+func decodeServerInfo(w http.ResponseWriter, r *http.Request) (*ProxyServerInfo, error) {
+	var info ProxyServerInfo
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&info); err != nil {
+		return nil, fmt.Errorf("invalid body: %w", err)
+	}
+	return &info, nil
+}
+
+func adminListServersHandle(w http.ResponseWriter, r *http.Request) {
+	servers := serverRegistry.Servers()
+	if servers == nil {
+		servers = []*ProxyServerInfo{}
+	}
+	writeJSON(w, http.StatusOK, servers)
+}
+
+func adminGetServerHandle(w http.ResponseWriter, r *http.Request) {
+	server := serverRegistry.Find(r.PathValue("id"))
+	if server == nil {
+		http.Error(w, errServerNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, server)
+}
+
+func adminCreateServerHandle(w http.ResponseWriter, r *http.Request) {
+	info, err := decodeServerInfo(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = serverRegistry.Update(func(servers []*ProxyServerInfo) ([]*ProxyServerInfo, error) {
+		if slices.ContainsFunc(servers, func(s *ProxyServerInfo) bool { return s.ID == info.ID }) {
+			return nil, errServerExists
+		}
+		return append(servers, info), nil
+	})
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, serverRegistry.Find(info.ID))
+}
+
+func adminUpdateServerHandle(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	info, err := decodeServerInfo(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if info.ID == "" {
+		info.ID = id
+	}
+
+	err = serverRegistry.Update(func(servers []*ProxyServerInfo) ([]*ProxyServerInfo, error) {
+		i := slices.IndexFunc(servers, func(s *ProxyServerInfo) bool { return s.ID == id })
+		if i < 0 {
+			return nil, errServerNotFound
+		}
+		if info.ID != id && slices.ContainsFunc(servers, func(s *ProxyServerInfo) bool { return s.ID == info.ID }) {
+			return nil, errServerExists
+		}
+		servers[i] = info
+		return servers, nil
+	})
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, serverRegistry.Find(info.ID))
+}
+
+func adminDeleteServerHandle(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := serverRegistry.Update(func(servers []*ProxyServerInfo) ([]*ProxyServerInfo, error) {
+		i := slices.IndexFunc(servers, func(s *ProxyServerInfo) bool { return s.ID == id })
+		if i < 0 {
+			return nil, errServerNotFound
+		}
+		return slices.Delete(servers, i, i+1), nil
+	})
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func adminReorderServersHandle(w http.ResponseWriter, r *http.Request) {
+	var req reorderRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	err := serverRegistry.Update(func(servers []*ProxyServerInfo) ([]*ProxyServerInfo, error) {
+		if len(req.IDs) != len(servers) {
+			return nil, fmt.Errorf("ids must list all %d servers", len(servers))
+		}
+		byID := make(map[string]*ProxyServerInfo, len(servers))
+		for _, s := range servers {
+			byID[s.ID] = s
+		}
+		result := make([]*ProxyServerInfo, 0, len(servers))
+		for _, id := range req.IDs {
+			s, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", errServerNotFound, id)
+			}
+			delete(byID, id)
+			result = append(result, s)
+		}
+		return result, nil
+	})
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, serverRegistry.Servers())
+}
+
+func registerAdminHandlers(mux *http.ServeMux, prefix string) {
+	base := prefix + "/admin/api/servers"
+
+	mux.HandleFunc("GET "+base, adminAuth(adminListServersHandle))
+	mux.HandleFunc("POST "+base, adminAuth(adminCreateServerHandle))
+	mux.HandleFunc("POST "+base+"/reorder", adminAuth(adminReorderServersHandle))
+	mux.HandleFunc("GET "+base+"/{id}", adminAuth(adminGetServerHandle))
+	mux.HandleFunc("PUT "+base+"/{id}", adminAuth(adminUpdateServerHandle))
+	mux.HandleFunc("DELETE "+base+"/{id}", adminAuth(adminDeleteServerHandle))
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const quotaAlertInterval = 5 * time.Minute
+
+var quotaAlerter *QuotaAlerter
+
+type quotaAlertState struct {
+	CycleStart int64     `json:"cycleStart"`
+	Fired      []float64 `json:"fired"`
+}
+
+type QuotaAlerter struct {
+	path       string
+	thresholds []float64
+	notify     func(ctx context.Context, text string) error
+	mu         sync.Mutex
+	state      map[string]*quotaAlertState
+}
+
+func ParseQuotaThresholds(s string) ([]float64, error) {
+	var result []float64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(part), "%"))
+		if part == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid quota threshold %q", part)
+		}
+		result = append(result, v)
+	}
+	slices.Sort(result)
+	return slices.Compact(result), nil
+}
+
+func NewQuotaAlerter(path string, thresholds []float64, notify func(ctx context.Context, text string) error) (*QuotaAlerter, error) {
+	a := &QuotaAlerter{
+		path:       path,
+		thresholds: thresholds,
+		notify:     notify,
+		state:      make(map[string]*quotaAlertState),
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &a.state); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *QuotaAlerter) Run(ctx context.Context) {
+	ticker := time.NewTicker(quotaAlertInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if overview, err := fleetOverview.Get(ctx); err == nil {
+				a.Check(ctx, overview)
+			}
+		}
+	}
+}
+
+func (a *QuotaAlerter) Check(ctx context.Context, overview *Overview) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	changed := false
+	active := make(map[string]bool, len(overview.Servers))
+	for _, server := range overview.Servers {
+		active[server.ID] = true
+		if server.Quota == nil {
+			continue
+		}
+
+		st, ok := a.state[server.ID]
+		if !ok || st.CycleStart != server.Quota.CycleStart {
+			st = &quotaAlertState{CycleStart: server.Quota.CycleStart}
+			a.state[server.ID] = st
+			changed = true
+		}
+
+		var crossed []float64
+		for _, t := range a.thresholds {
+			if server.Quota.Percent >= t && !slices.Contains(st.Fired, t) {
+				crossed = append(crossed, t)
+			}
+		}
+		if len(crossed) == 0 {
+			continue
+		}
+
+		if err := a.notify(ctx, quotaAlertText(server, crossed[len(crossed)-1])); err != nil {
+			log.Printf("Quota alert for %s not sent: %v", server.ID, err)
+			continue
+		}
+		st.Fired = append(st.Fired, crossed...)
+		changed = true
+	}
+
+	for id := range a.state {
+		if !active[id] {
+			delete(a.state, id)
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := a.save(); err != nil {
+			log.Printf("Quota alert state save error: %v", err)
+		}
+	}
+}
+
+func (a *QuotaAlerter) save() error {
+	content, err := json.Marshal(a.state)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(a.path, content, 0644)
+}
+
+func quotaAlertText(server ServerOverview, threshold float64) string {
+	quota := server.Quota
+	icon := "⚠️"
+	if threshold >= 100 {
+		icon = "🛑"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s <b>%s</b>: использовано %.1f%% трафика (порог %s%%)\n",
+		icon, html.EscapeString(server.Name), quota.Percent, strconv.FormatFloat(threshold, 'f', -1, 64))
+	fmt.Fprintf(&b, "%s из %s", formatBytesIEC(quota.UsedBytes), formatBytesIEC(quota.LimitBytes))
+	if quota.Direction != QuotaBoth {
+		fmt.Fprintf(&b, " (%s)", quota.Direction)
+	}
+	fmt.Fprintf(&b, "\nСброс: %s", time.Unix(quota.CycleEnd, 0).Format("02.01.2006"))
+	if quota.ProjectedExhaustion != nil && quota.RemainingBytes > 0 {
+		fmt.Fprintf(&b, "\nПрогноз исчерпания: %s", time.Unix(*quota.ProjectedExhaustion, 0).Format("02.01.2006 15:04"))
+	}
+	return b.String()
+}
@@ -3,6 +3,9 @@ const pubVars = {};
 let pubVarsIsLoad = false;
 let currentSection;
 let serverList = [];
+let currentServerIndex = -1;
+let latestOverview = null;
+let serverStream = null;
 
 function parseProxyUrl(url) {
 	const urlObj = new URL(url);
@@ -167,38 +170,61 @@ async function showServer(index) {
 
 	tariffPlanEl.textContent = serverList[index].plan || '';
 	speedLimitEl.textContent = serverList[index].speedRate || '';
-	trafficLimitEl.textContent = serverList[index].limit || '';
+	trafficLimitEl.textContent = formatTrafficLimit(serverList[index].limit);
 
 	document.getElementById('serverInfoTable').hidden = false;
 	document.getElementById('serverLoadHeader').hidden = false;
 	document.getElementById('serverLoadScaleContainer').hidden = false;
 	document.getElementById('serverHeaderContainer').hidden = false;
 
-	(async () => {
-		try {
-			const res = await fetch(`./serverinfo/?url=${serverList[index].infoLink}/stat`);
-			if (!res.ok) throw new Error('stat fetch failed');
-			const stat = await res.json();
-			const day30Tx = stat.day30Tx || 0;
-			const day30Rx = stat.day30Rx || 0;
-			const total = (day30Tx * 0.7) + day30Rx;
-			const totalGb = total / (1024 * 1024 * 1024);
-			const percentage = (totalGb / 3100.0) * 100;
-			updateServerLoad(percentage.toFixed(1));
-		} catch (e) {
-			updateServerLoad(0);
-		}
-	})();
+	currentServerIndex = index;
+	if (latestOverview) {
+		renderServerLoad(latestOverview);
+	} else {
+		fetch('./api/overview')
+			.then(r => r.json())
+			.then(overview => {
+				latestOverview = overview;
+				renderServerLoad(overview);
+			})
+			.catch(() => updateServerLoad(0));
+	}
 
 	const proxyContainer = document.getElementById('serverProxyList');
 	proxyContainer.innerHTML = '';
 	const proxyLinks = serverList[index].proxyLinks || {};
 	appendProxyBlock(proxyContainer, 'Xray VLESS Reality', proxyLinks.vless);
+	appendProxyBlock(proxyContainer, 'Trojan', proxyLinks.trojan);
+	appendProxyBlock(proxyContainer, 'Shadowsocks', proxyLinks.ss);
+	appendProxyBlock(proxyContainer, 'Hysteria2', proxyLinks.hysteria2);
+	appendProxyBlock(proxyContainer, 'TUIC', proxyLinks.tuic);
+	appendProxyBlock(proxyContainer, 'WireGuard', proxyLinks.wireguard);
 	appendProxyBlock(proxyContainer, 'HTTP Proxy', proxyLinks.http);
 	appendProxyBlock(proxyContainer, 'SOCKS Proxy', proxyLinks.socks);
 	renderSmartProxyBlock(proxyContainer, proxyLinks.http, serverList[index].name || '');
 }
 
+function renderSubscriptionBlock() {
+	const container = document.getElementById('subscriptionBlock');
+	if (!container) return;
+	container.innerHTML = '';
+	const row = document.createElement('p');
+	row.className = 'flex-jc-sb';
+	const code = document.createElement('code');
+	const pre = document.createElement('pre');
+	pre.innerText = new URL('./sub', window.location.href).href;
+	code.appendChild(pre);
+	row.appendChild(code);
+	row.appendChild(createCopyButton(() => pre.innerText));
+	container.appendChild(row);
+	const qr = document.createElement('img');
+	qr.src = './qr?sub&format=svg';
+	qr.alt = 'QR';
+	qr.width = 160;
+	qr.className = 'border1';
+	container.appendChild(qr);
+}
+
 function buildServersTable() {
 	if (!serverList || !Array.isArray(serverList)) return;
 	const table = document.getElementById('serversTable');
@@ -225,13 +251,8 @@ function buildServersTable() {
 
 		const statusTd = document.createElement('td');
 		statusTd.style.textAlign = 'center';
-		fetch(`./serverinfo/?url=${s.infoLink}/ping`).then(res => {
-			if (res.ok) {
-				res.text().then(t => statusTd.textContent = t === 'pong' ? '🟢' : '🔴');
-			} else {
-				statusTd.textContent = '🔴';
-			}
-		}).catch(() => statusTd.textContent = '🔴');
+		statusTd.dataset.serverId = s.id || '';
+		statusTd.textContent = '⚪';
 
 		const actionTd = document.createElement('td');
 		const btn = document.createElement('button');
@@ -251,6 +272,70 @@ function buildServersTable() {
 		table.appendChild(row);
 	});
 	if (serverList.length > 0) showServer(0);
+	if (!openServerStream()) loadServersHealth();
+}
+
+function renderServerHealth(health) {
+	const statusTd = document.querySelector(`#serversTable td[data-server-id="${CSS.escape(health.id)}"]`);
+	if (!statusTd) return;
+	if (health.status === 'up') {
+		statusTd.textContent = '🟢';
+		statusTd.title = `${health.latencyMs} ms`;
+	} else if (health.status === 'down') {
+		statusTd.textContent = '🔴';
+		statusTd.title = health.lastError || '';
+	} else {
+		statusTd.textContent = '⚪';
+		statusTd.title = '';
+	}
+}
+
+function formatTrafficLimit(limit) {
+	if (!limit) return '';
+	if (typeof limit === 'string') return limit;
+	if (limit.display) return limit.display;
+	if (!limit.bytes) return '';
+	const tib = limit.bytes / Math.pow(1024, 4);
+	const size = tib >= 1 ? `${+tib.toFixed(2)} TiB` : `${+(limit.bytes / Math.pow(1024, 3)).toFixed(2)} GiB`;
+	return limit.direction && limit.direction !== 'both' ? `${size} (${limit.direction})` : size;
+}
+
+function renderServerLoad(overview) {
+	const server = serverList[currentServerIndex];
+	if (!server) return;
+	const entry = ((overview && overview.servers) || []).find(s => s.id === server.id);
+	if (!entry || !entry.ok) {
+		updateServerLoad(0);
+		return;
+	}
+	if (entry.quota) {
+		updateServerLoad(entry.quota.percent);
+		return;
+	}
+	const day30Tx = entry.stat.day30Tx || 0;
+	const day30Rx = entry.stat.day30Rx || 0;
+	const total = (day30Tx * 0.7) + day30Rx;
+	const totalGb = total / (1024 * 1024 * 1024);
+	const percentage = (totalGb / 3100.0) * 100;
+	updateServerLoad(percentage.toFixed(1));
+}
+
+function openServerStream() {
+	if (serverStream || typeof EventSource === 'undefined') return false;
+	serverStream = new EventSource('./api/stream');
+	serverStream.addEventListener('health', e => renderServerHealth(JSON.parse(e.data)));
+	serverStream.addEventListener('overview', e => {
+		latestOverview = JSON.parse(e.data);
+		renderServerLoad(latestOverview);
+	});
+	return true;
+}
+
+function loadServersHealth() {
+	fetch('./api/health')
+		.then(r => r.json())
+		.then(list => (list || []).forEach(renderServerHealth))
+		.catch(() => { });
 }
 
 function renderSection(sectionEl, html) {
@@ -266,6 +351,7 @@ function renderSection(sectionEl, html) {
 			})
 			.then(dd => {
 				serverList = JSON.parse(dd) || [];
+				renderSubscriptionBlock();
 				buildServersTable();
 			});
 	}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const clashAutoGroup = "Auto"
+const clashProxyGroup = "PROXY"
+const proxyTestURL = "https://www.gstatic.com/generate_204"
+
+var yamlPlainRegexp = regexp.MustCompile(`^[A-Za-z_/.][A-Za-z0-9_ ./()-]*$`)
+
+type yamlField struct {
+	Key   string
+	Value any
+}
+
+type yamlMap []yamlField
+
+func (m *yamlMap) set(key string, value any) {
+	*m = append(*m, yamlField{key, value})
+}
+
+func (m *yamlMap) setString(key, value string) {
+	if value != "" {
+		m.set(key, value)
+	}
+}
+
+func yamlScalar(v any) string {
+	switch v := v.(type) {
+	case string:
+		switch strings.ToLower(v) {
+		case "true", "false", "yes", "no", "on", "off", "null", "~":
+			return strconv.Quote(v)
+		}
+		if yamlPlainRegexp.MatchString(v) && !strings.HasSuffix(v, " ") {
+			return v
+		}
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func writeYAML(sb *strings.Builder, value any, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch v := value.(type) {
+	case yamlMap:
+		for _, f := range v {
+			switch fv := f.Value.(type) {
+			case yamlMap:
+				fmt.Fprintf(sb, "%s%s:\n", pad, f.Key)
+				writeYAML(sb, fv, indent+1)
+			case []yamlMap, []string:
+				if reflect.ValueOf(fv).Len() == 0 {
+					fmt.Fprintf(sb, "%s%s: []\n", pad, f.Key)
+					continue
+				}
+				fmt.Fprintf(sb, "%s%s:\n", pad, f.Key)
+				writeYAML(sb, fv, indent)
+			default:
+				fmt.Fprintf(sb, "%s%s: %s\n", pad, f.Key, yamlScalar(fv))
+			}
+		}
+	case []yamlMap:
+		for _, item := range v {
+			var inner strings.Builder
+			writeYAML(&inner, item, indent+1)
+			sb.WriteString(pad + "- " + strings.TrimPrefix(inner.String(), pad+"  "))
+		}
+	case []string:
+		for _, item := range v {
+			fmt.Fprintf(sb, "%s- %s\n", pad, yamlScalar(item))
+		}
+	}
+}
+
+func uniqueProxyName(used map[string]bool, name string) string {
+	result := name
+	for i := 2; used[result]; i++ {
+		result = fmt.Sprintf("%s %d", name, i)
+	}
+	used[result] = true
+	return result
+}
+
+func clashTransport(proxy *yamlMap, link *ParsedLink) {
+	network := link.Params.Get("type")
+	switch network {
+	case "", "tcp":
+		return
+	case "ws":
+		proxy.set("network", "ws")
+		opts := yamlMap{}
+		opts.setString("path", link.Params.Get("path"))
+		if host := link.Params.Get("host"); host != "" {
+			opts.set("headers", yamlMap{{"Host", host}})
+		}
+		proxy.set("ws-opts", opts)
+	case "grpc":
+		proxy.set("network", "grpc")
+		proxy.set("grpc-opts", yamlMap{{"grpc-service-name", link.Params.Get("serviceName")}})
+	case "http", "h2":
+		proxy.set("network", "h2")
+		opts := yamlMap{}
+		opts.setString("path", link.Params.Get("path"))
+		if host := link.Params.Get("host"); host != "" {
+			opts.set("host", []string{host})
+		}
+		proxy.set("h2-opts", opts)
+	default:
+		proxy.set("network", network)
+	}
+}
+
+func clashTLS(proxy *yamlMap, link *ParsedLink, sniKey string) {
+	security := link.Params.Get("security")
+	if link.Protocol == "vless" {
+		if security != "tls" && security != "reality" {
+			return
+		}
+		proxy.set("tls", true)
+	}
+	proxy.setString(sniKey, link.Params.Get("sni"))
+	proxy.setString("flow", link.Params.Get("flow"))
+	proxy.setString("client-fingerprint", link.Params.Get("fp"))
+	if alpn := link.Params.Get("alpn"); alpn != "" {
+		proxy.set("alpn", strings.Split(alpn, ","))
+	}
+	if isTrueParam(link.Params.Get("allowInsecure")) {
+		proxy.set("skip-cert-verify", true)
+	}
+	if security == "reality" {
+		opts := yamlMap{{"public-key", link.Params.Get("pbk")}}
+		opts.setString("short-id", link.Params.Get("sid"))
+		proxy.set("reality-opts", opts)
+	}
+}
+
+func isTrueParam(v string) bool {
+	return v == "1" || v == "true"
+}
+
+func clashProxy(name string, link *ParsedLink) (yamlMap, error) {
+	proxy := yamlMap{
+		{"name", name},
+		{"server", link.Host},
+		{"port", link.Port},
+	}
+
+	switch link.Protocol {
+	case "vless":
+		proxy.set("type", "vless")
+		proxy.set("uuid", link.Username)
+		proxy.set("udp", true)
+		clashTLS(&proxy, link, "servername")
+		clashTransport(&proxy, link)
+	case "trojan":
+		proxy.set("type", "trojan")
+		proxy.set("password", link.Password)
+		proxy.set("udp", true)
+		clashTLS(&proxy, link, "sni")
+		clashTransport(&proxy, link)
+	case "ss":
+		proxy.set("type", "ss")
+		proxy.set("cipher", link.Method)
+		proxy.set("password", link.Password)
+		proxy.set("udp", true)
+	case "hysteria2":
+		proxy.set("type", "hysteria2")
+		proxy.set("password", link.Password)
+		proxy.setString("sni", link.Params.Get("sni"))
+		proxy.setString("obfs", link.Params.Get("obfs"))
+		proxy.setString("obfs-password", link.Params.Get("obfs-password"))
+		if isTrueParam(link.Params.Get("insecure")) {
+			proxy.set("skip-cert-verify", true)
+		}
+	case "tuic":
+		proxy.set("type", "tuic")
+		proxy.set("uuid", link.Username)
+		proxy.set("password", link.Password)
+		proxy.setString("sni", link.Params.Get("sni"))
+		if alpn := link.Params.Get("alpn"); alpn != "" {
+			proxy.set("alpn", strings.Split(alpn, ","))
+		}
+		proxy.setString("congestion-controller", link.Params.Get("congestion_control"))
+		proxy.setString("udp-relay-mode", link.Params.Get("udp_relay_mode"))
+		if isTrueParam(link.Params.Get("allow_insecure")) {
+			proxy.set("skip-cert-verify", true)
+		}
+	case "wireguard":
+		proxy.set("type", "wireguard")
+		clashWireGuardAddresses(&proxy, link)
+		proxy.set("private-key", link.Password)
+		proxy.set("public-key", link.Params.Get("publickey"))
+		proxy.setString("pre-shared-key", link.Params.Get("presharedkey"))
+		if mtu, err := strconv.Atoi(link.Params.Get("mtu")); err == nil {
+			proxy.set("mtu", mtu)
+		}
+		proxy.set("udp", true)
+	case "http":
+		proxy.set("type", "http")
+		proxy.setString("username", link.Username)
+		proxy.setString("password", link.Password)
+		if link.Scheme == "https" {
+			proxy.set("tls", true)
+		}
+	case "socks":
+		proxy.set("type", "socks5")
+		proxy.setString("username", link.Username)
+		proxy.setString("password", link.Password)
+		proxy.set("udp", true)
+	default:
+		return nil, fmt.Errorf("unsupported protocol %q", link.Protocol)
+	}
+
+	return proxy, nil
+}
+
+// clashWireGuardAddresses sets the first IPv4 and IPv6 interface addresses.
+// Clash has no field for further addresses, so they are routed through the
+// tunnel via allowed-ips next to the peer's own list.
+func clashWireGuardAddresses(proxy *yamlMap, link *ParsedLink) {
+	var ipv4, ipv6 string
+	var extra []string
+	for _, a := range strings.Split(link.Params.Get("address"), ",") {
+		addr := strings.TrimSpace(a)
+		if prefix, err := netip.ParsePrefix(addr); err == nil {
+			addr = prefix.Addr().String()
+		}
+		ip, err := netip.ParseAddr(addr)
+		switch {
+		case err != nil:
+			continue
+		case ip.Is4() && ipv4 == "":
+			ipv4 = ip.String()
+		case !ip.Is4() && ipv6 == "":
+			ipv6 = ip.String()
+		default:
+			extra = append(extra, netip.PrefixFrom(ip, ip.BitLen()).String())
+		}
+	}
+	proxy.setString("ip", ipv4)
+	proxy.setString("ipv6", ipv6)
+
+	allowed := []string{"0.0.0.0/0", "::/0"}
+	if s := link.Params.Get("allowedips"); s != "" {
+		allowed = nil
+		for _, a := range strings.Split(s, ",") {
+			allowed = append(allowed, strings.TrimSpace(a))
+		}
+	} else if len(extra) == 0 {
+		return
+	}
+	proxy.set("allowed-ips", append(allowed, extra...))
+}
+
+func BuildClashProfile(servers []*ProxyServerInfo) string {
+	used := make(map[string]bool)
+	var proxies []yamlMap
+	var names []string
+
+	for _, server := range servers {
+		server.ProxyLinks.Each(func(protocol *ProxyProtocol, index int, raw string) {
+			link, err := protocol.Parse(raw)
+			if err != nil {
+				return
+			}
+			name := uniqueProxyName(used, subscriptionRemark(server, protocol, index))
+			proxy, err := clashProxy(name, link)
+			if err != nil {
+				return
+			}
+			proxies = append(proxies, proxy)
+			names = append(names, name)
+		})
+	}
+
+	autoProxies := names
+	if len(autoProxies) == 0 {
+		autoProxies = []string{"DIRECT"}
+	}
+
+	profile := yamlMap{
+		{"mixed-port", 7890},
+		{"allow-lan", false},
+		{"mode", "rule"},
+		{"log-level", "info"},
+		{"ipv6", false},
+		{"proxies", proxies},
+		{"proxy-groups", []yamlMap{
+			{
+				{"name", clashProxyGroup},
+				{"type", "select"},
+				{"proxies", append([]string{clashAutoGroup, "DIRECT"}, names...)},
+			},
+			{
+				{"name", clashAutoGroup},
+				{"type", "url-test"},
+				{"url", proxyTestURL},
+				{"interval", 300},
+				{"tolerance", 50},
+				{"proxies", autoProxies},
+			},
+		}},
+		{"rules", []string{
+			"GEOIP,LAN,DIRECT,no-resolve",
+			"DOMAIN-SUFFIX,local,DIRECT",
+			"MATCH," + clashProxyGroup,
+		}},
+	}
+
+	var sb strings.Builder
+	writeYAML(&sb, profile, 0)
+	return sb.String()
+}
+
+func clashProfileHandle(w http.ResponseWriter, r *http.Request) {
+	setSubscriptionHeaders(r.Context(), w.Header())
+	w.Header().Set("Content-Type", "text/yaml; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="proxyhub.yaml"`)
+
+	fmt.Fprint(w, BuildClashProfile(serverRegistry.Servers()))
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildClashProfileGolden(t *testing.T) {
+	for _, tc := range goldenProtocols {
+		t.Run(tc.name, func(t *testing.T) {
+			got := BuildClashProfile(goldenServer(tc.links))
+			if !strings.Contains(got, `server: "203.0.113.10"`) {
+				t.Fatalf("no proxy generated:\n%s", got)
+			}
+			checkGolden(t, filepath.Join("testdata", "clash", tc.name+".yaml"), []byte(got))
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+func diskUsage(path string) (total, avail uint64, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
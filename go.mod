@@ -3,6 +3,6 @@ module github.com/nikita55612/ProxyHub
 go 1.25.4
 
 require (
-	github.com/go-telegram/bot v1.17.0 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
+	github.com/go-telegram/bot v1.17.0
+	github.com/joho/godotenv v1.5.1
 )
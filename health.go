@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HealthUnknown = "unknown"
+	HealthUp      = "up"
+	HealthDown    = "down"
+)
+
+var healthChecker *HealthChecker
+
+type ServerHealth struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+	LastError string `json:"lastError,omitempty"`
+	CheckedAt int64  `json:"checkedAt"`
+	LastUpAt  int64  `json:"lastUpAt"`
+}
+
+type HealthChecker struct {
+	interval time.Duration
+	client   *http.Client
+	mu       sync.RWMutex
+	results  map[string]*ServerHealth
+}
+
+func NewHealthChecker(interval time.Duration, client *http.Client) *HealthChecker {
+	return &HealthChecker{
+		interval: interval,
+		client:   client,
+		results:  make(map[string]*ServerHealth),
+	}
+}
+
+func (h *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *HealthChecker) checkAll(ctx context.Context) {
+	servers := serverRegistry.Servers()
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := h.ping(ctx, server.InfoLink)
+			if ctx.Err() != nil {
+				return
+			}
+			h.record(server, result)
+		}()
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	active := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		active[server.ID] = true
+	}
+	for id := range h.results {
+		if !active[id] {
+			delete(h.results, id)
+		}
+	}
+	h.mu.Unlock()
+
+	if uptimeStore != nil {
+		uptimeStore.Prune(servers)
+		if err := uptimeStore.Save(); err != nil {
+			log.Printf("Uptime history save error: %v", err)
+		}
+	}
+}
+
+type pingResult struct {
+	latency time.Duration
+	err     error
+}
+
+func (h *HealthChecker) ping(ctx context.Context, infoLink string) pingResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(infoLink, "/")+"/ping", nil)
+	if err != nil {
+		return pingResult{err: err}
+	}
+
+	start := time.Now()
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return pingResult{err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	latency := time.Since(start)
+	if err != nil {
+		return pingResult{latency: latency, err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return pingResult{latency: latency, err: fmt.Errorf("unexpected status %s", resp.Status)}
+	}
+	if strings.TrimSpace(string(body)) != "pong" {
+		return pingResult{latency: latency, err: fmt.Errorf("unexpected response %q", body)}
+	}
+
+	return pingResult{latency: latency}
+}
+
+func (h *HealthChecker) record(server *ProxyServerInfo, result pingResult) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev := h.results[server.ID]
+	health := &ServerHealth{
+		ID:        server.ID,
+		Name:      server.Name,
+		Status:    HealthUp,
+		LatencyMs: result.latency.Milliseconds(),
+		CheckedAt: time.Now().Unix(),
+	}
+	if prev != nil {
+		health.LastUpAt = prev.LastUpAt
+	}
+
+	if result.err != nil {
+		health.Status = HealthDown
+		health.LastError = result.err.Error()
+	} else {
+		health.LastUpAt = health.CheckedAt
+	}
+
+	if prev != nil && prev.Status != health.Status {
+		log.Printf("Server %s is %s: %s", server.ID, health.Status, health.LastError)
+	}
+
+	h.results[server.ID] = health
+
+	if eventStream != nil && (prev == nil || prev.Status != health.Status) {
+		eventStream.Publish("health", health)
+	}
+
+	if uptimeStore != nil {
+		uptimeStore.Record(*health, result.latency)
+	}
+}
+
+func (h *HealthChecker) Get(id string) ServerHealth {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if health, ok := h.results[id]; ok {
+		return *health
+	}
+	return ServerHealth{ID: id, Status: HealthUnknown}
+}
+
+func (h *HealthChecker) All() []ServerHealth {
+	servers := serverRegistry.Servers()
+	result := make([]ServerHealth, 0, len(servers))
+	for _, server := range servers {
+		health := h.Get(server.ID)
+		health.Name = server.Name
+		result = append(result, health)
+	}
+	return result
+}
+
+func healthHandle(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthChecker.All())
+}
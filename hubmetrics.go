@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var hubMetrics = NewHubMetrics()
+
+type MetricsServerParams struct {
+	Name    string
+	Host    string
+	Port    int
+	Handler http.HandlerFunc
+}
+
+type routeMetrics struct {
+	requests map[int]uint64
+	buckets  []uint64
+	sum      float64
+	count    uint64
+}
+
+type HubMetrics struct {
+	mu             sync.Mutex
+	routes         map[string]*routeMetrics
+	upstreamErrors map[[2]string]uint64
+	broadcasts     map[string]uint64
+}
+
+func NewHubMetrics() *HubMetrics {
+	return &HubMetrics{
+		routes:         make(map[string]*routeMetrics),
+		upstreamErrors: make(map[[2]string]uint64),
+		broadcasts:     make(map[string]uint64),
+	}
+}
+
+func (m *HubMetrics) ObserveRequest(route string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rm, ok := m.routes[route]
+	if !ok {
+		rm = &routeMetrics{
+			requests: make(map[int]uint64),
+			buckets:  make([]uint64, len(latencyBuckets)),
+		}
+		m.routes[route] = rm
+	}
+
+	seconds := duration.Seconds()
+	rm.requests[status]++
+	rm.sum += seconds
+	rm.count++
+	for i, le := range latencyBuckets {
+		if seconds <= le {
+			rm.buckets[i]++
+		}
+	}
+}
+
+func (m *HubMetrics) UpstreamError(infoLink, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.upstreamErrors[[2]string{infoLink, reason}]++
+}
+
+func (m *HubMetrics) BroadcastResult(err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.broadcasts[result]++
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func instrumentHandler(prefix string, mux *http.ServeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		mux.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		route := "unmatched"
+		if r.Pattern != "" {
+			method, path, found := strings.Cut(r.Pattern, " ")
+			if !found {
+				method, path = "", method
+			}
+			route = strings.TrimSpace(method + " " + strings.TrimPrefix(path, prefix))
+		}
+		hubMetrics.ObserveRequest(route, rec.status, time.Since(start))
+	}
+}
+
+func (m *HubMetrics) write(p *promWriter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	routes := make([]string, 0, len(m.routes))
+	for route := range m.routes {
+		routes = append(routes, route)
+	}
+	slices.Sort(routes)
+
+	p.header("proxyhub_http_requests_total", "counter", "HTTP requests handled by the hub, by route and status code.")
+	for _, route := range routes {
+		rm := m.routes[route]
+		codes := make([]int, 0, len(rm.requests))
+		for code := range rm.requests {
+			codes = append(codes, code)
+		}
+		slices.Sort(codes)
+		for _, code := range codes {
+			p.sample("proxyhub_http_requests_total", float64(rm.requests[code]), "route", route, "code", strconv.Itoa(code))
+		}
+	}
+
+	p.header("proxyhub_http_request_duration_seconds", "histogram", "HTTP request latency by route.")
+	for _, route := range routes {
+		rm := m.routes[route]
+		for i, le := range latencyBuckets {
+			p.sample("proxyhub_http_request_duration_seconds_bucket", float64(rm.buckets[i]), "route", route, "le", formatPromValue(le))
+		}
+		p.sample("proxyhub_http_request_duration_seconds_bucket", float64(rm.count), "route", route, "le", "+Inf")
+		p.sample("proxyhub_http_request_duration_seconds_sum", rm.sum, "route", route)
+		p.sample("proxyhub_http_request_duration_seconds_count", float64(rm.count), "route", route)
+	}
+
+	p.header("proxyhub_serverinfo_upstream_errors_total", "counter", "Failed serverinfo proxy requests, by infoLink and reason.")
+	upstreamKeys := make([][2]string, 0, len(m.upstreamErrors))
+	for key := range m.upstreamErrors {
+		upstreamKeys = append(upstreamKeys, key)
+	}
+	slices.SortFunc(upstreamKeys, func(a, b [2]string) int {
+		return strings.Compare(a[0]+"\x00"+a[1], b[0]+"\x00"+b[1])
+	})
+	for _, key := range upstreamKeys {
+		p.sample("proxyhub_serverinfo_upstream_errors_total", float64(m.upstreamErrors[key]), "info_link", key[0], "reason", key[1])
+	}
+
+	p.header("proxyhub_telegram_broadcast_messages_total", "counter", "Broadcast messages forwarded to Telegram users, by result.")
+	for _, result := range []string{"ok", "error"} {
+		p.sample("proxyhub_telegram_broadcast_messages_total", float64(m.broadcasts[result]), "result", result)
+	}
+}
+
+func writeHubMetrics(p *promWriter) {
+	hubMetrics.write(p)
+
+	p.metric("proxyhub_telegram_users", "gauge", "Registered Telegram bot users.", float64(GetUsersCount()))
+
+	if healthChecker == nil {
+		return
+	}
+	servers := healthChecker.All()
+	p.header("proxyhub_server_up", "gauge", "Whether the server infoserver answered the last health check (1 up, 0 down).")
+	for _, health := range servers {
+		if health.Status == HealthUnknown {
+			continue
+		}
+		up := 0.0
+		if health.Status == HealthUp {
+			up = 1
+		}
+		p.sample("proxyhub_server_up", up, "id", health.ID, "name", health.Name)
+	}
+	p.header("proxyhub_server_ping_latency_seconds", "gauge", "Latency of the last successful health check.")
+	for _, health := range servers {
+		if health.Status == HealthUp {
+			p.sample("proxyhub_server_ping_latency_seconds", float64(health.LatencyMs)/1000, "id", health.ID, "name", health.Name)
+		}
+	}
+	p.header("proxyhub_server_last_up_timestamp_seconds", "gauge", "Unix time the server was last seen up.")
+	for _, health := range servers {
+		if health.LastUpAt > 0 {
+			p.sample("proxyhub_server_last_up_timestamp_seconds", float64(health.LastUpAt), "id", health.ID, "name", health.Name)
+		}
+	}
+}
+
+func hubMetricsHandle(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	writeHubMetrics(&promWriter{w: &buf})
+
+	w.Header().Set("Content-Type", metricsContentType)
+	w.Write(buf.Bytes())
+}
+
+func RunMetricsServer(ctx context.Context, params *MetricsServerParams) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", params.Handler)
+
+	addr := fmt.Sprintf("%s:%d", params.Host, params.Port)
+	server := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	log.Printf("%s server running at http://%s/metrics\n", params.Name, addr)
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("%s server shutdown error: %v", params.Name, err)
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("%s server failed: %v", params.Name, err)
+	}
+}
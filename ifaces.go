@@ -0,0 +1,121 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+const allInterfaces = "all"
+
+var errEmptyTrafficData = errors.New("empty data")
+
+type InterfaceSummary struct {
+	Name    string         `json:"name"`
+	Alias   string         `json:"alias"`
+	Created VnStatTimeData `json:"created"`
+	Updated VnStatTimeData `json:"updated"`
+	Total   TrafficStats   `json:"total"`
+}
+
+type interfaceNotFoundError struct {
+	name string
+}
+
+func (e *interfaceNotFoundError) Error() string {
+	return fmt.Sprintf("interface %q not found", e.name)
+}
+
+func mergeTrafficEntries(dst, src []DayStats) []DayStats {
+	for _, entry := range src {
+		i, found := slices.BinarySearchFunc(dst, entry.Timestamp, func(e DayStats, ts int64) int {
+			return cmp.Compare(e.Timestamp, ts)
+		})
+		if found {
+			dst[i].Rx += entry.Rx
+			dst[i].Tx += entry.Tx
+			continue
+		}
+		dst = slices.Insert(dst, i, entry)
+	}
+	for i := range dst {
+		dst[i].ID = i
+	}
+	return dst
+}
+
+func aggregateInterfaces(ifaces []VnStatInterface) VnStatInterface {
+	result := VnStatInterface{Name: allInterfaces}
+	for i, iface := range ifaces {
+		if i == 0 || iface.Created.Timestamp < result.Created.Timestamp {
+			result.Created = iface.Created
+		}
+		if i == 0 || iface.Updated.Timestamp > result.Updated.Timestamp {
+			result.Updated = iface.Updated
+		}
+
+		t := &result.Traffic
+		t.Total.Rx += iface.Traffic.Total.Rx
+		t.Total.Tx += iface.Traffic.Total.Tx
+		t.FiveMinute = mergeTrafficEntries(t.FiveMinute, iface.Traffic.FiveMinute)
+		t.Hour = mergeTrafficEntries(t.Hour, iface.Traffic.Hour)
+		t.Day = mergeTrafficEntries(t.Day, iface.Traffic.Day)
+		t.Month = mergeTrafficEntries(t.Month, iface.Traffic.Month)
+		t.Year = mergeTrafficEntries(t.Year, iface.Traffic.Year)
+	}
+	return result
+}
+
+func selectInterface(data *VnStatData, name string) (*VnStatInterface, error) {
+	if len(data.Interfaces) == 0 {
+		return nil, errEmptyTrafficData
+	}
+
+	switch name {
+	case "":
+		return &data.Interfaces[0], nil
+	case allInterfaces:
+		iface := aggregateInterfaces(data.Interfaces)
+		return &iface, nil
+	}
+
+	i := slices.IndexFunc(data.Interfaces, func(iface VnStatInterface) bool {
+		return iface.Name == name || (iface.Alias != "" && iface.Alias == name)
+	})
+	if i < 0 {
+		return nil, &interfaceNotFoundError{name: name}
+	}
+	return &data.Interfaces[i], nil
+}
+
+func statFromInterface(iface *VnStatInterface) *Stat {
+	result := new(Stat)
+	days := iface.Traffic.Day
+
+	if len(days) > 0 {
+		lastDay := days[len(days)-1]
+		result.DayRx = lastDay.Rx
+		result.DayTX = lastDay.Tx
+	}
+
+	for i, day := range days {
+		result.Day30Rx += day.Rx
+		result.Day30TX += day.Tx
+		if i >= len(days)-7 {
+			result.Day7Rx += day.Rx
+			result.Day7TX += day.Tx
+		}
+	}
+
+	return result
+}
+
+func trafficErrorStatus(err error) int {
+	var notFound *interfaceNotFoundError
+	if errors.As(err, &notFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadGateway
+}
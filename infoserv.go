@@ -2,13 +2,13 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os/exec"
-	"strconv"
-	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -16,12 +16,20 @@ import (
 var infoTsLast atomic.Int64
 var infoCache = ""
 
-var statTsLast atomic.Int64
-var statCache = ""
+var statCacheMu sync.Mutex
+var statCache struct {
+	ts   int64
+	data *VnStatData
+}
 
 type InfoServerParams struct {
-	Host string
-	Port int
+	Host         string
+	Port         int
+	TrafficFile  string
+	SharedSecret string
+	TLS          InfoTLSParams
+	MetricsHost  string
+	MetricsPort  int
 }
 
 type VnStatData struct {
@@ -46,8 +54,8 @@ type VnStatTimeData struct {
 
 type VnStatDate struct {
 	Year  int `json:"year"`
-	Month int `json:"month"`
-	Day   int `json:"day"`
+	Month int `json:"month,omitempty"`
+	Day   int `json:"day,omitempty"`
 }
 
 type VnStatTime struct {
@@ -56,8 +64,13 @@ type VnStatTime struct {
 }
 
 type VnStatTraffic struct {
-	Total TrafficStats `json:"total"`
-	Day   []DayStats   `json:"day"`
+	Total      TrafficStats `json:"total"`
+	FiveMinute []DayStats   `json:"fiveminute,omitempty"`
+	Hour       []DayStats   `json:"hour,omitempty"`
+	Day        []DayStats   `json:"day,omitempty"`
+	Month      []DayStats   `json:"month,omitempty"`
+	Year       []DayStats   `json:"year,omitempty"`
+	Top        []DayStats   `json:"top,omitempty"`
 }
 
 type TrafficStats struct {
@@ -66,11 +79,12 @@ type TrafficStats struct {
 }
 
 type DayStats struct {
-	ID        int        `json:"id"`
-	Date      VnStatDate `json:"date"`
-	Timestamp int64      `json:"timestamp"`
-	Rx        uint64     `json:"rx"`
-	Tx        uint64     `json:"tx"`
+	ID        int         `json:"id"`
+	Date      VnStatDate  `json:"date"`
+	Time      *VnStatTime `json:"time,omitempty"`
+	Timestamp int64       `json:"timestamp"`
+	Rx        uint64      `json:"rx"`
+	Tx        uint64      `json:"tx"`
 }
 
 type Stat struct {
@@ -91,6 +105,10 @@ func execCommand(name string, arg ...string) string {
 	return string(stdout)
 }
 
+func readTrafficData(mode string, limit int) (*VnStatData, error) {
+	return readTrafficQuery(&TrafficQuery{Mode: TrafficMode(mode), Limit: limit})
+}
+
 func allowCorsHeader(h http.Header) {
 	h.Set("Access-Control-Allow-Origin", "*")
 	h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
@@ -108,7 +126,7 @@ func infoHandle(w http.ResponseWriter, r *http.Request) {
 	allowCorsHeader(header)
 
 	infoCache = ""
-	infoCache += strings.ReplaceAll(strings.ReplaceAll(execCommand("fastfetch", "--pipe", "--structure", "separator:os:separator:host:kernel:uptime:packages:shell:de:wm:wmtheme:theme:icons:font:cpu:gpu:memory:disk:localip"), "[34C", ""), "[31C", "")
+	infoCache += systemCollector.Collect().String() + "\n"
 	infoCache += execCommand("vnstat")
 	infoCache += execCommand("vnstat", "-h")
 	infoCache += execCommand("vnstat", "-hg")
@@ -119,102 +137,135 @@ func infoHandle(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, infoCache)
 }
 
-func statHandle(w http.ResponseWriter, r *http.Request) {
+// cachedDailyTraffic shares one 30-day read between /stat and /metrics for 12 seconds.
+func cachedDailyTraffic() (*VnStatData, error) {
+	statCacheMu.Lock()
+	defer statCacheMu.Unlock()
+
 	tsNow := time.Now().Unix()
-	if tsNow-statTsLast.Load() <= 12 {
-		fmt.Fprint(w, statCache)
-		return
+	if statCache.data != nil && tsNow-statCache.ts <= 12 {
+		return statCache.data, nil
 	}
 
+	vnStat, err := readTrafficData("d", 30)
+	if err != nil {
+		return nil, err
+	}
+	statCache.ts, statCache.data = tsNow, vnStat
+	return vnStat, nil
+}
+
+func statHandle(w http.ResponseWriter, r *http.Request) {
 	header := w.Header()
 	allowCorsHeader(header)
 
-	exc := execCommand("vnstat", "--json", "d", "30")
-	if exc == "" {
-		http.Error(w, "exec command error", http.StatusBadRequest)
+	vnStat, err := cachedDailyTraffic()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
 	}
 
-	var vnStat VnStatData
-	err := json.Unmarshal([]byte(exc), &vnStat)
+	iface, err := selectInterface(vnStat, r.URL.Query().Get("interface"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), trafficErrorStatus(err))
 		return
 	}
 
-	result := new(Stat)
+	header.Set("Content-Type", "application/json")
+
+	b, _ := json.Marshal(statFromInterface(iface))
 
-	if len(vnStat.Interfaces) == 0 {
-		http.Error(w, "empty data", http.StatusBadRequest)
+	fmt.Fprint(w, string(b))
+}
+
+func rawStatHandle(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	allowCorsHeader(header)
+
+	query, err := ParseTrafficQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	days := vnStat.Interfaces[0].Traffic.Day
-
-	if len(days) > 0 {
-		lastDay := days[len(days)-1]
-		result.DayRx = lastDay.Rx
-		result.DayTX = lastDay.Tx
+	vnStat, err := readTrafficQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
 	}
 
-	for i, day := range days {
-		result.Day30Rx += day.Rx
-		result.Day30TX += day.Tx
-		if i >= len(days)-7 {
-			result.Day7Rx += day.Rx
-			result.Day7TX += day.Tx
+	if query.Interface != "" {
+		iface, err := selectInterface(vnStat, query.Interface)
+		if err != nil {
+			http.Error(w, err.Error(), trafficErrorStatus(err))
+			return
 		}
+		vnStat.Interfaces = []VnStatInterface{*iface}
 	}
 
 	header.Set("Content-Type", "application/json")
-
-	b, _ := json.Marshal(&result)
-
-	statCache = string(b)
-	statTsLast.Store(tsNow)
-
-	fmt.Fprint(w, statCache)
+	json.NewEncoder(w).Encode(vnStat)
 }
 
-func rawStatHandle(w http.ResponseWriter, r *http.Request) {
+func interfacesHandle(w http.ResponseWriter, r *http.Request) {
 	header := w.Header()
 	allowCorsHeader(header)
 
-	query := r.URL.Query()
-	mode := query.Get("mode")
-	if mode == "" {
-		mode = "d"
-	}
-	limit := query.Get("limit")
-	if limit == "" {
-		limit = "30"
-	}
-	li, err := strconv.Atoi(limit)
-	if err == nil {
-		if li > 90 {
-			http.Error(w, "error", http.StatusBadRequest)
-			return
-		}
-	}
-	result := execCommand("vnstat", "--json", mode, limit)
-	if result == "" {
-		http.Error(w, "exec command error", http.StatusBadRequest)
+	vnStat, err := readTrafficData("d", 1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
 	}
 
-	header.Set("Content-Type", "application/json")
+	result := make([]InterfaceSummary, 0, len(vnStat.Interfaces))
+	for _, iface := range vnStat.Interfaces {
+		result = append(result, InterfaceSummary{
+			Name:    iface.Name,
+			Alias:   iface.Alias,
+			Created: iface.Created,
+			Updated: iface.Updated,
+			Total:   iface.Traffic.Total,
+		})
+	}
 
-	fmt.Fprint(w, result)
+	header.Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
 }
 
 func RunInfoServer(ctx context.Context, stop context.CancelFunc, params *InfoServerParams) {
 	defer stop()
 
+	var err error
+	trafficSampler, err = NewTrafficSampler(systemCollector, params.TrafficFile)
+	if err != nil {
+		log.Fatalf("traffic history error: %v", err)
+	}
+	go trafficSampler.Run(ctx)
+
+	// Prometheus cannot sign requests, so node metrics get their own
+	// listener, loopback-only unless the operator chooses otherwise.
+	if params.MetricsPort > 0 {
+		go RunMetricsServer(ctx, &MetricsServerParams{
+			Name:    "Node metrics",
+			Host:    params.MetricsHost,
+			Port:    params.MetricsPort,
+			Handler: nodeMetricsHandle,
+		})
+	}
+
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/info", infoHandle)
-	mux.HandleFunc("/stat", statHandle)
-	mux.HandleFunc("/rawstat", rawStatHandle)
+	var verifier *SignatureVerifier
+	if params.SharedSecret != "" {
+		verifier = NewSignatureVerifier([]byte(params.SharedSecret))
+		log.Println("Info server requires signed requests")
+	}
+
+	mux.HandleFunc("/info", verifier.Require(infoHandle))
+	mux.HandleFunc("/info.json", verifier.Require(infoJSONHandle))
+	mux.HandleFunc("/stat", verifier.Require(statHandle))
+	mux.HandleFunc("/rawstat", verifier.Require(rawStatHandle))
+	mux.HandleFunc("/interfaces", verifier.Require(interfacesHandle))
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
 		header := w.Header()
 		allowCorsHeader(header)
@@ -227,8 +278,20 @@ func RunInfoServer(ctx context.Context, stop context.CancelFunc, params *InfoSer
 		Handler: mux,
 	}
 
-	log.Printf("Info server running [LOCAL] at http://127.0.0.1:%d\n", params.Port)
-	log.Printf("Info server running [GLOBAL] at http://%s:%d\n", PublicIPAddr, params.Port)
+	proto := "http"
+	if params.TLS.Enabled() {
+		server.TLSConfig, err = infoServerTLSConfig(&params.TLS)
+		if err != nil {
+			log.Fatalf("info server TLS error: %v", err)
+		}
+		proto = "https"
+		if server.TLSConfig.ClientAuth == tls.RequireAndVerifyClientCert {
+			log.Println("Info server requires client certificates")
+		}
+	}
+
+	log.Printf("Info server running [LOCAL] at %s://127.0.0.1:%d\n", proto, params.Port)
+	log.Printf("Info server running [GLOBAL] at %s://%s:%d\n", proto, PublicIPAddr, params.Port)
 
 	go func() {
 		<-ctx.Done()
@@ -241,7 +304,12 @@ func RunInfoServer(ctx context.Context, stop context.CancelFunc, params *InfoSer
 		}
 	}()
 
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if proto == "https" {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
 		log.Fatalf("Info server failed: %v", err)
 	}
 
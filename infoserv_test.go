@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeVnStat puts a vnstat on PATH that prints to stderr and exits 1.
+func fakeVnStat(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho 'Error: Database load failed' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "vnstat"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func serveTraffic(handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+	statCache.data = nil
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, target, nil))
+	return rr
+}
+
+func TestTrafficHandlersBackendFailure(t *testing.T) {
+	handlers := []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+	}{
+		{"stat", statHandle, "/stat"},
+		{"rawstat", rawStatHandle, "/rawstat"},
+		{"interfaces", interfacesHandle, "/interfaces"},
+	}
+	backends := []struct {
+		name  string
+		setup func(t *testing.T)
+	}{
+		{"vnstat missing without sampler", func(t *testing.T) { setupNodeInfoTest(t, false) }},
+		{"vnstat failing", func(t *testing.T) { setupNodeInfoTest(t, true); fakeVnStat(t) }},
+	}
+
+	for _, backend := range backends {
+		for _, tc := range handlers {
+			t.Run(backend.name+"/"+tc.name, func(t *testing.T) {
+				backend.setup(t)
+				rr := serveTraffic(tc.handler, tc.target)
+				if rr.Code != http.StatusBadGateway {
+					t.Errorf("status = %d, want 502; body %q", rr.Code, rr.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestTrafficHandlersClientErrors(t *testing.T) {
+	setupNodeInfoTest(t, true)
+
+	tests := []struct {
+		handler http.HandlerFunc
+		target  string
+		want    int
+	}{
+		{statHandle, "/stat", http.StatusOK},
+		{statHandle, "/stat?interface=nope", http.StatusNotFound},
+		{rawStatHandle, "/rawstat?mode=x", http.StatusBadRequest},
+		{rawStatHandle, "/rawstat?interface=nope", http.StatusNotFound},
+		{interfacesHandle, "/interfaces", http.StatusOK},
+	}
+	for _, tc := range tests {
+		if rr := serveTraffic(tc.handler, tc.target); rr.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d; body %q", tc.target, rr.Code, tc.want, rr.Body.String())
+		}
+	}
+}
+
+func TestTrafficErrorStatus(t *testing.T) {
+	if got := trafficErrorStatus(&interfaceNotFoundError{name: "x"}); got != http.StatusNotFound {
+		t.Errorf("not found: status = %d, want 404", got)
+	}
+	if got := trafficErrorStatus(errEmptyTrafficData); got != http.StatusBadGateway {
+		t.Errorf("empty data: status = %d, want 502", got)
+	}
+}
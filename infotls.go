@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// infoClient is shared by every hub request to info servers so that
+// keep-alive connections are pooled in a single transport.
+var infoClient = newInfoClient(4*time.Second, nil)
+
+type InfoTLSParams struct {
+	CrtFile string
+	KeyFile string
+	CAFile  string
+}
+
+func (p *InfoTLSParams) Enabled() bool {
+	return p != nil && (p.CrtFile != "" || p.KeyFile != "" || p.CAFile != "")
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("%s: no certificates found", path)
+	}
+	return pool, nil
+}
+
+// Server side: CrtFile and KeyFile enable TLS, CAFile additionally
+// requires every client to present a certificate signed by that CA.
+func infoServerTLSConfig(p *InfoTLSParams) (*tls.Config, error) {
+	if p.CrtFile == "" || p.KeyFile == "" {
+		return nil, errors.New("info server TLS requires both certificate and key")
+	}
+	cert, err := tls.LoadX509KeyPair(p.CrtFile, p.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if p.CAFile != "" {
+		pool, err := loadCertPool(p.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// Hub side: CrtFile and KeyFile are presented to info servers that require
+// client certificates, CAFile verifies info servers with private certificates.
+func infoHubTLSConfig(p *InfoTLSParams) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if p.CrtFile != "" || p.KeyFile != "" {
+		if p.CrtFile == "" || p.KeyFile == "" {
+			return nil, errors.New("hub client certificate requires both certificate and key")
+		}
+		cert, err := tls.LoadX509KeyPair(p.CrtFile, p.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if p.CAFile != "" {
+		pool, err := loadCertPool(p.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+func newInfoClient(timeout time.Duration, config *tls.Config) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if config != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = config
+		client.Transport = transport
+	}
+	return client
+}
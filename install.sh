@@ -72,7 +72,12 @@ EOF
         "proxyLinks": {
             "vless": [],
             "http": [],
-            "socks": []
+            "socks": [],
+            "trojan": [],
+            "ss": [],
+            "hysteria2": [],
+            "tuic": [],
+            "wireguard": []
         }
     }
 ]
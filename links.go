@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var shadowsocksMethods = []string{
+	"aes-128-gcm",
+	"aes-192-gcm",
+	"aes-256-gcm",
+	"chacha20-ietf-poly1305",
+	"xchacha20-ietf-poly1305",
+	"2022-blake3-aes-128-gcm",
+	"2022-blake3-aes-256-gcm",
+	"2022-blake3-chacha20-poly1305",
+}
+
+type ProxyProtocol struct {
+	Key   string
+	Title string
+	Links func(*ProxyLinks) []string
+	Parse func(string) (*ParsedLink, error)
+}
+
+var proxyProtocols = []ProxyProtocol{
+	{"vless", "Xray VLESS Reality", func(l *ProxyLinks) []string { return l.Vless }, ParseVlessLink},
+	{"http", "HTTP Proxy", func(l *ProxyLinks) []string { return l.HTTP }, ParseHTTPLink},
+	{"socks", "SOCKS Proxy", func(l *ProxyLinks) []string { return l.Socks }, ParseSocksLink},
+	{"trojan", "Trojan", func(l *ProxyLinks) []string { return l.Trojan }, ParseTrojanLink},
+	{"ss", "Shadowsocks", func(l *ProxyLinks) []string { return l.Shadowsocks }, ParseShadowsocksLink},
+	{"hysteria2", "Hysteria2", func(l *ProxyLinks) []string { return l.Hysteria2 }, ParseHysteria2Link},
+	{"tuic", "TUIC", func(l *ProxyLinks) []string { return l.TUIC }, ParseTUICLink},
+	{"wireguard", "WireGuard", func(l *ProxyLinks) []string { return l.WireGuard }, ParseWireGuardLink},
+}
+
+type ParsedLink struct {
+	Protocol string
+	Scheme   string
+	Raw      string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Method   string
+	Params   url.Values
+	Remark   string
+}
+
+func (l *ParsedLink) Address() string {
+	return net.JoinHostPort(l.Host, strconv.Itoa(l.Port))
+}
+
+func (l *ProxyLinks) Each(fn func(protocol *ProxyProtocol, index int, raw string)) {
+	for i := range proxyProtocols {
+		for j, raw := range proxyProtocols[i].Links(l) {
+			fn(&proxyProtocols[i], j, raw)
+		}
+	}
+}
+
+func (l *ProxyLinks) Parsed() []*ParsedLink {
+	var result []*ParsedLink
+	l.Each(func(protocol *ProxyProtocol, _ int, raw string) {
+		if link, err := protocol.Parse(raw); err == nil {
+			result = append(result, link)
+		}
+	})
+	return result
+}
+
+func parseLinkURL(raw string, protocol string, schemes ...string) (*ParsedLink, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		return nil, fmt.Errorf("scheme must be one of %v, got %q", schemes, u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("host is required")
+	}
+	if u.Port() == "" {
+		return nil, fmt.Errorf("port is required")
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %q", u.Port())
+	}
+
+	link := &ParsedLink{
+		Protocol: protocol,
+		Scheme:   u.Scheme,
+		Raw:      raw,
+		Host:     u.Hostname(),
+		Port:     port,
+		Params:   u.Query(),
+		Remark:   u.Fragment,
+	}
+	if u.User != nil {
+		link.Username = u.User.Username()
+		link.Password, _ = u.User.Password()
+	}
+
+	return link, nil
+}
+
+func validateLinkSecurity(link *ParsedLink, allowed ...string) error {
+	security := link.Params.Get("security")
+	if !slices.Contains(allowed, security) {
+		return fmt.Errorf("unknown security %q", security)
+	}
+	if security == "reality" {
+		if link.Params.Get("pbk") == "" {
+			return fmt.Errorf("reality requires pbk")
+		}
+		if link.Params.Get("sni") == "" {
+			return fmt.Errorf("reality requires sni")
+		}
+	}
+	return nil
+}
+
+func ParseVlessLink(raw string) (*ParsedLink, error) {
+	link, err := parseLinkURL(raw, "vless", "vless")
+	if err != nil {
+		return nil, err
+	}
+	if !uuidRegexp.MatchString(link.Username) {
+		return nil, fmt.Errorf("user id must be a UUID")
+	}
+	if err := validateLinkSecurity(link, "", "none", "tls", "reality"); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func ParseTrojanLink(raw string) (*ParsedLink, error) {
+	link, err := parseLinkURL(raw, "trojan", "trojan")
+	if err != nil {
+		return nil, err
+	}
+	if link.Username == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+	link.Password, link.Username = link.Username, ""
+	if err := validateLinkSecurity(link, "", "tls", "reality"); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func ParseShadowsocksLink(raw string) (*ParsedLink, error) {
+	raw = strings.TrimSpace(raw)
+	rest, ok := strings.CutPrefix(raw, "ss://")
+	if !ok {
+		return nil, fmt.Errorf("scheme must be ss")
+	}
+
+	body, fragment, _ := strings.Cut(rest, "#")
+	if !strings.Contains(body, "@") {
+		decoded, err := decodeBase64(strings.SplitN(body, "?", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid legacy ss link: %w", err)
+		}
+		body = decoded
+	}
+
+	userInfo, hostPart, ok := cutLast(body, "@")
+	if !ok {
+		return nil, fmt.Errorf("user info is required")
+	}
+	if !strings.Contains(userInfo, ":") {
+		decoded, err := decodeBase64(userInfo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user info: %w", err)
+		}
+		method, password, _ := strings.Cut(decoded, ":")
+		userInfo = url.UserPassword(method, password).String()
+	}
+
+	normalized := "ss://" + userInfo + "@" + hostPart
+	if fragment != "" {
+		normalized += "#" + fragment
+	}
+	link, err := parseLinkURL(normalized, "ss", "ss")
+	if err != nil {
+		return nil, err
+	}
+	link.Raw = raw
+	link.Method, link.Username = link.Username, ""
+	if !slices.Contains(shadowsocksMethods, link.Method) {
+		return nil, fmt.Errorf("unsupported method %q", link.Method)
+	}
+	if link.Password == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+
+	return link, nil
+}
+
+func ParseHysteria2Link(raw string) (*ParsedLink, error) {
+	link, err := parseLinkURL(raw, "hysteria2", "hysteria2", "hy2")
+	if err != nil {
+		return nil, err
+	}
+	if link.Password == "" {
+		link.Password, link.Username = link.Username, ""
+	} else {
+		link.Password, link.Username = link.Username+":"+link.Password, ""
+	}
+	if link.Password == "" {
+		return nil, fmt.Errorf("auth is required")
+	}
+	if obfs := link.Params.Get("obfs"); obfs != "" {
+		if obfs != "salamander" {
+			return nil, fmt.Errorf("unknown obfs %q", obfs)
+		}
+		if link.Params.Get("obfs-password") == "" {
+			return nil, fmt.Errorf("obfs requires obfs-password")
+		}
+	}
+
+	return link, nil
+}
+
+func ParseTUICLink(raw string) (*ParsedLink, error) {
+	link, err := parseLinkURL(raw, "tuic", "tuic")
+	if err != nil {
+		return nil, err
+	}
+	if !uuidRegexp.MatchString(link.Username) {
+		return nil, fmt.Errorf("user id must be a UUID")
+	}
+	if link.Password == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+	switch cc := link.Params.Get("congestion_control"); cc {
+	case "", "bbr", "cubic", "new_reno":
+	default:
+		return nil, fmt.Errorf("unknown congestion_control %q", cc)
+	}
+
+	return link, nil
+}
+
+func ParseWireGuardLink(raw string) (*ParsedLink, error) {
+	if strings.HasPrefix(strings.TrimSpace(raw), "[Interface]") {
+		return parseWireGuardConfig(raw)
+	}
+
+	escaped := escapeWireGuardUserinfo(strings.TrimSpace(raw))
+	link, err := parseLinkURL(escaped, "wireguard", "wireguard", "wg")
+	if err != nil {
+		return nil, err
+	}
+	link.Raw = raw
+	if link.Password != "" {
+		link.Username += ":" + link.Password
+	}
+	link.Password, link.Username = link.Username, ""
+	if u, err := url.Parse(escaped); err == nil {
+		for _, key := range []string{"publickey", "presharedkey"} {
+			if value, ok := rawQueryParam(u.RawQuery, key); ok {
+				link.Params.Set(key, value)
+			}
+		}
+	}
+	if err := validateWireGuardKey("private key", link.Password); err != nil {
+		return nil, err
+	}
+	if err := validateWireGuardKey("publickey", link.Params.Get("publickey")); err != nil {
+		return nil, err
+	}
+	if err := validateWireGuardAddress(link.Params.Get("address")); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func parseWireGuardConfig(raw string) (*ParsedLink, error) {
+	values := map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.Trim(line, "[]"))
+			if section == "peer" && values["peer.publickey"] != "" {
+				return nil, fmt.Errorf("only one [Peer] is supported")
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		values[section+"."+strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+
+	endpoint := values["peer.endpoint"]
+	if endpoint == "" {
+		return nil, fmt.Errorf("[Peer] Endpoint is required")
+	}
+	host, portStr, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Endpoint: %w", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid Endpoint port %q", portStr)
+	}
+
+	link := &ParsedLink{
+		Protocol: "wireguard",
+		Scheme:   "wireguard",
+		Raw:      raw,
+		Host:     host,
+		Port:     port,
+		Password: values["interface.privatekey"],
+		Params:   url.Values{},
+	}
+	for key, param := range map[string]string{
+		"peer.publickey":    "publickey",
+		"peer.presharedkey": "presharedkey",
+		"peer.allowedips":   "allowedips",
+		"interface.address": "address",
+		"interface.dns":     "dns",
+		"interface.mtu":     "mtu",
+	} {
+		if values[key] != "" {
+			link.Params.Set(param, values[key])
+		}
+	}
+
+	if err := validateWireGuardKey("PrivateKey", link.Password); err != nil {
+		return nil, err
+	}
+	if err := validateWireGuardKey("PublicKey", link.Params.Get("publickey")); err != nil {
+		return nil, err
+	}
+	if err := validateWireGuardAddress(link.Params.Get("address")); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// escapeWireGuardUserinfo percent-encodes "/" in the private key, which
+// base64 keys pasted without escaping contain and which would otherwise end
+// the authority before the host.
+func escapeWireGuardUserinfo(raw string) string {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		return raw
+	}
+	authority := rest
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		authority = rest[:i]
+	}
+	at := strings.LastIndex(authority, "@")
+	if at < 0 {
+		return raw
+	}
+	return scheme + "://" + strings.ReplaceAll(rest[:at], "/", "%2F") + rest[at:]
+}
+
+// rawQueryParam reads a query value without turning "+" into a space, so
+// base64 keys pasted without percent-encoding keep their plus signs.
+func rawQueryParam(rawQuery, key string) (string, bool) {
+	for _, pair := range strings.Split(rawQuery, "&") {
+		k, v, _ := strings.Cut(pair, "=")
+		if k, err := url.PathUnescape(k); err != nil || k != key {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(v); err == nil {
+			return unescaped, true
+		}
+		return v, true
+	}
+	return "", false
+}
+
+func validateWireGuardKey(name, key string) error {
+	if key == "" {
+		return fmt.Errorf("%s is required", name)
+	}
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(b) != 32 {
+		return fmt.Errorf("%s must be a base64 encoded 32 byte key", name)
+	}
+	return nil
+}
+
+func validateWireGuardAddress(address string) error {
+	if address == "" {
+		return fmt.Errorf("address is required")
+	}
+	for _, a := range strings.Split(address, ",") {
+		a = strings.TrimSpace(a)
+		if _, err := netip.ParsePrefix(a); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(a); err != nil {
+			return fmt.Errorf("invalid address %q", a)
+		}
+	}
+	return nil
+}
+
+func ParseHTTPLink(raw string) (*ParsedLink, error) {
+	return parseLinkURL(raw, "http", "http", "https")
+}
+
+func ParseSocksLink(raw string) (*ParsedLink, error) {
+	return parseLinkURL(raw, "socks", "socks5", "socks", "socks5h")
+}
+
+func decodeBase64(s string) (string, error) {
+	s = strings.TrimRight(s, "=")
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return string(b), nil
+	}
+	b, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
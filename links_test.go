@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseWireGuardLinkPlusInKeys(t *testing.T) {
+	const (
+		publicKey    = "x+IBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="
+		presharedKey = "FpCyhws9cxwWoV4xELtfJvjJN+zQVRPISllRWgeopVE="
+	)
+
+	base := "wireguard://" + testWGPrivate + "@203.0.113.10:51820?"
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"unescaped", "publickey=" + publicKey + "&presharedkey=" + presharedKey + "&address=10.0.0.2/32"},
+		{"escaped", url.Values{
+			"publickey":    {publicKey},
+			"presharedkey": {presharedKey},
+			"address":      {"10.0.0.2/32"},
+		}.Encode()},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			link, err := ParseWireGuardLink(base + tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := link.Params.Get("publickey"); got != publicKey {
+				t.Errorf("publickey = %q, want %q", got, publicKey)
+			}
+			if got := link.Params.Get("presharedkey"); got != presharedKey {
+				t.Errorf("presharedkey = %q, want %q", got, presharedKey)
+			}
+			if got := link.Params.Get("address"); got != "10.0.0.2/32" {
+				t.Errorf("address = %q", got)
+			}
+		})
+	}
+}
+
+func TestParseWireGuardLinkSlashInPrivateKey(t *testing.T) {
+	const privateKey = "aPxGwq8zERHQ3Q1cOZFdJ+cvJX5Ka4mLN/n/l6JuGQ8="
+
+	tests := []string{
+		"wireguard://" + privateKey + "@203.0.113.10:51820?publickey=" + testWGPublic + "&address=10.0.0.2/32#wg",
+		"wireguard://" + url.QueryEscape(privateKey) + "@203.0.113.10:51820?publickey=" + testWGPublic + "&address=10.0.0.2/32#wg",
+	}
+	for _, raw := range tests {
+		link, err := ParseWireGuardLink(raw)
+		if err != nil {
+			t.Errorf("ParseWireGuardLink(%q) error: %v", raw, err)
+			continue
+		}
+		if link.Password != privateKey {
+			t.Errorf("private key = %q, want %q", link.Password, privateKey)
+		}
+		if link.Host != "203.0.113.10" || link.Port != 51820 || link.Raw != raw {
+			t.Errorf("link = %+v", link)
+		}
+	}
+}
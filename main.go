@@ -10,6 +10,7 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -20,13 +21,19 @@ var (
 )
 
 const (
-	defaultMode       = 1
-	defaultDir        = "."
-	defaultHost       = "0.0.0.0"
-	defaultPort       = 8090
-	defaultPoroto     = "http"
-	defaultInfoPort   = 8091
-	defaultRootPrefix = ""
+	defaultMode           = 1
+	defaultDir            = "."
+	defaultHost           = "0.0.0.0"
+	defaultPort           = 8090
+	defaultPoroto         = "http"
+	defaultInfoPort       = 8091
+	defaultRootPrefix     = ""
+	defaultHealthTick     = 30 * time.Second
+	defaultProbeTick      = 60 * time.Second
+	defaultProbeURL       = ""
+	defaultMetricPort     = 9092
+	defaultNodeMetricPort = 9093
+	defaultQuotaAlert     = "80,95,100"
 )
 
 type Config struct {
@@ -40,6 +47,20 @@ type Config struct {
 	InfoHost string
 	InfoPort int
 	Mode     int
+	Health   time.Duration
+	Probe    time.Duration
+	ProbeURL string
+
+	InfoTLS InfoTLSParams
+	HubTLS  InfoTLSParams
+
+	MetricsHost string
+	MetricsPort int
+
+	NodeMetricsHost string
+	NodeMetricsPort int
+
+	QuotaAlerts []float64
 }
 
 func loadEnv() {
@@ -86,10 +107,29 @@ func parseFlags() Config {
 	certFile := flag.String("scrt", "server.crt", "server cert file")
 	infoHost := flag.String("ihost", defaultHost, "info server host")
 	infoPort := flag.Int("iport", defaultInfoPort, "info server port")
+	infoCertFile := flag.String("icrt", "", "info server cert file, enables https with -ikey")
+	infoKeyFile := flag.String("ikey", "", "info server key file")
+	infoCAFile := flag.String("ica", "", "CA file for required info server client certificates")
+	hubCertFile := flag.String("hcrt", "", "hub client cert file presented to info servers")
+	hubKeyFile := flag.String("hkey", "", "hub client key file")
+	hubCAFile := flag.String("hca", "", "CA file for verifying info server certificates")
 	mode := flag.Int("mode", defaultMode, "mode")
+	health := flag.Duration("health", defaultHealthTick, "server health check interval")
+	probe := flag.Duration("probe", defaultProbeTick, "proxy endpoint probe interval")
+	probeURL := flag.String("ptarget", defaultProbeURL, "small target url fetched through http/socks proxies on every probe, empty to disable")
+	metricsHost := flag.String("mhost", "127.0.0.1", "hub metrics server host")
+	metricsPort := flag.Int("mport", defaultMetricPort, "hub metrics server port, 0 to disable")
+	nodeMetricsHost := flag.String("nmhost", "127.0.0.1", "node metrics server host")
+	nodeMetricsPort := flag.Int("nmport", defaultNodeMetricPort, "node metrics server port, 0 to disable")
+	quotaAlerts := flag.String("qalerts", defaultQuotaAlert, "traffic quota alert thresholds in percent, empty to disable")
 
 	flag.Parse()
 
+	thresholds, err := ParseQuotaThresholds(*quotaAlerts)
+	if err != nil {
+		log.Fatalf("Invalid -qalerts: %v", err)
+	}
+
 	return Config{
 		Dir:      *dir,
 		Host:     *host,
@@ -101,6 +141,20 @@ func parseFlags() Config {
 		InfoHost: *infoHost,
 		InfoPort: *infoPort,
 		Mode:     *mode,
+		Health:   *health,
+		Probe:    *probe,
+		ProbeURL: *probeURL,
+
+		InfoTLS: InfoTLSParams{CrtFile: *infoCertFile, KeyFile: *infoKeyFile, CAFile: *infoCAFile},
+		HubTLS:  InfoTLSParams{CrtFile: *hubCertFile, KeyFile: *hubKeyFile, CAFile: *hubCAFile},
+
+		MetricsHost: *metricsHost,
+		MetricsPort: *metricsPort,
+
+		NodeMetricsHost: *nodeMetricsHost,
+		NodeMetricsPort: *nodeMetricsPort,
+
+		QuotaAlerts: thresholds,
 	}
 }
 
@@ -117,6 +171,10 @@ func getTelegramConfig() (string, string, string) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "servers" {
+		os.Exit(runServersCommand(os.Args[2:]))
+	}
+
 	loadEnv()
 
 	PubVars = make(map[string]string)
@@ -139,21 +197,39 @@ func main() {
 	defer stop()
 
 	go RunInfoServer(ctx, stop, &InfoServerParams{
-		Host: config.InfoHost,
-		Port: config.InfoPort,
+		Host:         config.InfoHost,
+		Port:         config.InfoPort,
+		TrafficFile:  "traffic.json",
+		SharedSecret: os.Getenv("INFO_SHARED_SECRET"),
+		TLS:          config.InfoTLS,
+		MetricsHost:  config.NodeMetricsHost,
+		MetricsPort:  config.NodeMetricsPort,
 	})
 
 	if config.Mode > 1 {
 		token, ownerID, accessCode := getTelegramConfig()
 
 		go RunServer(ctx, stop, &ServerParams{
-			Dir:     config.Dir,
-			Host:    config.Host,
-			Port:    config.Port,
-			Proto:   config.Proto,
-			KeyFile: config.KeyFile,
-			CrtFile: config.CertFile,
-			Prefix:  config.Prefix,
+			Dir:        config.Dir,
+			Host:       config.Host,
+			Port:       config.Port,
+			Proto:      config.Proto,
+			KeyFile:    config.KeyFile,
+			CrtFile:    config.CertFile,
+			Prefix:     config.Prefix,
+			AdminToken: os.Getenv("ADMIN_API_TOKEN"),
+			InfoSecret: os.Getenv("INFO_SHARED_SECRET"),
+			InfoTLS:    config.HubTLS,
+			Health:     config.Health,
+			Probe:      config.Probe,
+			ProbeURL:   config.ProbeURL,
+			UptimeFile: "uptime.json",
+
+			QuotaAlerts:    config.QuotaAlerts,
+			QuotaAlertFile: "quotaalerts.json",
+
+			MetricsHost: config.MetricsHost,
+			MetricsPort: config.MetricsPort,
 		})
 
 		go RunTelebot(ctx, stop, &TelebotParams{
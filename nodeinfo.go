@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const nodeInfoTTL = 4 * time.Second
+
+var nodeInfoMu sync.Mutex
+var nodeInfoCache = make(map[string]*NodeInfo)
+
+type NodeInfo struct {
+	System       NodeSystem      `json:"system"`
+	CPU          CPUInfo         `json:"cpu"`
+	Memory       MemoryInfo      `json:"memory"`
+	Disks        []DiskInfo      `json:"disks"`
+	Addresses    []LocalAddress  `json:"addresses"`
+	Traffic      *TrafficSummary `json:"traffic,omitempty"`
+	TrafficError string          `json:"trafficError,omitempty"`
+	GeneratedAt  int64           `json:"generatedAt"`
+}
+
+type NodeSystem struct {
+	Hostname      string     `json:"hostname"`
+	Kernel        string     `json:"kernel"`
+	OS            OSInfo     `json:"os"`
+	Load          [3]float64 `json:"load"`
+	UptimeSeconds float64    `json:"uptimeSeconds"`
+}
+
+type LocalAddress struct {
+	Interface string `json:"interface"`
+	Address   string `json:"address"`
+	Family    string `json:"family"`
+}
+
+type TrafficSummary struct {
+	Interface  string       `json:"interface"`
+	Total      TrafficStats `json:"total"`
+	Stat       *Stat        `json:"stat"`
+	FiveMinute []DayStats   `json:"fiveminute"`
+	Hours      []DayStats   `json:"hours"`
+	Days       []DayStats   `json:"days"`
+}
+
+func localAddresses() []LocalAddress {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	var result []LocalAddress
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			family := "ipv6"
+			if ipNet.IP.To4() != nil {
+				family = "ipv4"
+			}
+			result = append(result, LocalAddress{
+				Interface: iface.Name,
+				Address:   ipNet.String(),
+				Family:    family,
+			})
+		}
+	}
+	return result
+}
+
+func trafficInterface(mode string, limit int, name string) (*VnStatInterface, error) {
+	vnStat, err := readTrafficData(mode, limit)
+	if err != nil {
+		return nil, err
+	}
+	return selectInterface(vnStat, name)
+}
+
+func collectTrafficSummary(name string) (*TrafficSummary, error) {
+	days, err := trafficInterface("d", 30, name)
+	if err != nil {
+		return nil, err
+	}
+	summary := &TrafficSummary{
+		Interface: days.Name,
+		Total:     days.Traffic.Total,
+		Stat:      statFromInterface(days),
+		Days:      days.Traffic.Day,
+	}
+
+	if name == "" {
+		name = days.Name
+	}
+	if hours, err := trafficInterface("h", 24, name); err == nil {
+		summary.Hours = hours.Traffic.Hour
+	}
+	if fiveMinute, err := trafficInterface("f", 12, name); err == nil {
+		summary.FiveMinute = fiveMinute.Traffic.FiveMinute
+	}
+
+	return summary, nil
+}
+
+func collectNodeInfo(name string) (*NodeInfo, error) {
+	sys := systemCollector.Collect()
+	info := &NodeInfo{
+		System: NodeSystem{
+			Hostname:      sys.Hostname,
+			Kernel:        sys.Kernel,
+			OS:            sys.OS,
+			Load:          sys.Load,
+			UptimeSeconds: sys.UptimeSeconds,
+		},
+		CPU:         sys.CPU,
+		Memory:      sys.Memory,
+		Addresses:   localAddresses(),
+		GeneratedAt: time.Now().Unix(),
+	}
+	info.Disks, _ = systemCollector.Disks()
+
+	traffic, err := collectTrafficSummary(name)
+	if err != nil {
+		var notFound *interfaceNotFoundError
+		if errors.As(err, &notFound) {
+			return nil, err
+		}
+		info.TrafficError = err.Error()
+	}
+	info.Traffic = traffic
+
+	return info, nil
+}
+
+func infoJSONHandle(w http.ResponseWriter, r *http.Request) {
+	allowCorsHeader(w.Header())
+
+	name := r.URL.Query().Get("interface")
+
+	nodeInfoMu.Lock()
+	defer nodeInfoMu.Unlock()
+
+	info, ok := nodeInfoCache[name]
+	if !ok || time.Since(time.Unix(info.GeneratedAt, 0)) > nodeInfoTTL {
+		var err error
+		info, err = collectNodeInfo(name)
+		if err != nil {
+			http.Error(w, err.Error(), trafficErrorStatus(err))
+			return
+		}
+		// Only names that resolved to an interface are cached, which keeps
+		// the map bounded by the interfaces the node actually has.
+		if info.Traffic != nil {
+			nodeInfoCache[name] = info
+		}
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
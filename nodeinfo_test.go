@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setupNodeInfoTest(t *testing.T, withSampler bool) {
+	t.Helper()
+	t.Setenv("PATH", t.TempDir())
+
+	prevCollector, prevSampler := systemCollector, trafficSampler
+	t.Cleanup(func() {
+		systemCollector, trafficSampler = prevCollector, prevSampler
+		nodeInfoCache = make(map[string]*NodeInfo)
+	})
+	systemCollector = fixtureCollector
+	trafficSampler = nil
+	nodeInfoCache = make(map[string]*NodeInfo)
+
+	if !withSampler {
+		return
+	}
+	sampler, err := NewTrafficSampler(fixtureCollector, filepath.Join(t.TempDir(), "traffic.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	sampler.Sample(now)
+	sampler.state.Interfaces["eth0"].add(now.AddDate(0, 0, -1), 300, 30)
+	sampler.state.Interfaces["eth0"].add(now, 200, 20)
+	trafficSampler = sampler
+}
+
+func getNodeInfo(t *testing.T, target string) (int, *NodeInfo) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	infoJSONHandle(rr, httptest.NewRequest(http.MethodGet, target, nil))
+	if rr.Code != http.StatusOK {
+		return rr.Code, nil
+	}
+	info := new(NodeInfo)
+	if err := json.Unmarshal(rr.Body.Bytes(), info); err != nil {
+		t.Fatal(err)
+	}
+	return rr.Code, info
+}
+
+func TestInfoJSONHandle(t *testing.T) {
+	setupNodeInfoTest(t, true)
+
+	code, info := getNodeInfo(t, "/info.json")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if info.System.Hostname != "vps1" || info.System.Kernel != "6.1.0-18-amd64" || info.System.OS.ID != "debian" {
+		t.Errorf("system = %+v", info.System)
+	}
+	if info.CPU.Cores != 2 || info.Memory.TotalBytes != 2014548*1024 {
+		t.Errorf("cpu = %+v, memory = %+v", info.CPU, info.Memory)
+	}
+	if info.Traffic == nil || info.Traffic.Interface != "eth0" {
+		t.Fatalf("traffic = %+v, error %q", info.Traffic, info.TrafficError)
+	}
+	if len(info.Traffic.Days) != 2 || len(info.Traffic.Hours) == 0 || len(info.Traffic.FiveMinute) == 0 {
+		t.Errorf("traffic bins = %d days, %d hours, %d fiveminute",
+			len(info.Traffic.Days), len(info.Traffic.Hours), len(info.Traffic.FiveMinute))
+	}
+	if s := info.Traffic.Stat; s.DayRx != 200 || s.DayTX != 20 || s.Day30Rx != 500 || s.Day30TX != 50 {
+		t.Errorf("stat = %+v", s)
+	}
+	if _, ok := nodeInfoCache[""]; !ok {
+		t.Error("resolved interface not cached")
+	}
+}
+
+func TestInfoJSONHandleUnknownInterface(t *testing.T) {
+	setupNodeInfoTest(t, true)
+
+	if code, _ := getNodeInfo(t, "/info.json?interface=nope"); code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", code)
+	}
+	if len(nodeInfoCache) != 0 {
+		t.Errorf("cache has %d entries after unknown interface", len(nodeInfoCache))
+	}
+}
+
+func TestInfoJSONHandleWithoutTraffic(t *testing.T) {
+	setupNodeInfoTest(t, false)
+
+	code, info := getNodeInfo(t, "/info.json?interface=eth0")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if info.Traffic != nil || info.TrafficError == "" {
+		t.Errorf("traffic = %+v, error %q; want error only", info.Traffic, info.TrafficError)
+	}
+	if len(nodeInfoCache) != 0 {
+		t.Errorf("cache has %d entries after traffic error", len(nodeInfoCache))
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+)
+
+func writeNodeMetrics(p *promWriter) {
+	if counters := systemCollector.NetCounters(); len(counters) > 0 {
+		p.header("proxyhub_node_network_receive_bytes_total", "counter", "Bytes received by the network interface.")
+		for _, c := range counters {
+			p.sample("proxyhub_node_network_receive_bytes_total", float64(c.RxBytes), "interface", c.Name)
+		}
+		p.header("proxyhub_node_network_transmit_bytes_total", "counter", "Bytes transmitted by the network interface.")
+		for _, c := range counters {
+			p.sample("proxyhub_node_network_transmit_bytes_total", float64(c.TxBytes), "interface", c.Name)
+		}
+	}
+
+	if vnStat, err := cachedDailyTraffic(); err == nil {
+		p.header("proxyhub_node_vnstat_total_bytes", "gauge", "All-time traffic recorded by vnstat.")
+		for _, iface := range vnStat.Interfaces {
+			p.sample("proxyhub_node_vnstat_total_bytes", float64(iface.Traffic.Total.Rx), "interface", iface.Name, "direction", "rx")
+			p.sample("proxyhub_node_vnstat_total_bytes", float64(iface.Traffic.Total.Tx), "interface", iface.Name, "direction", "tx")
+		}
+		p.header("proxyhub_node_vnstat_period_bytes", "gauge", "Traffic recorded by vnstat today and over the last 7 and 30 days.")
+		for _, iface := range vnStat.Interfaces {
+			stat := statFromInterface(&iface)
+			for _, s := range []struct {
+				period string
+				rx, tx uint64
+			}{
+				{"today", stat.DayRx, stat.DayTX},
+				{"7d", stat.Day7Rx, stat.Day7TX},
+				{"30d", stat.Day30Rx, stat.Day30TX},
+			} {
+				p.sample("proxyhub_node_vnstat_period_bytes", float64(s.rx), "interface", iface.Name, "period", s.period, "direction", "rx")
+				p.sample("proxyhub_node_vnstat_period_bytes", float64(s.tx), "interface", iface.Name, "period", s.period, "direction", "tx")
+			}
+		}
+	}
+
+	if cpu, err := systemCollector.CPUSeconds(); err == nil {
+		p.header("proxyhub_node_cpu_seconds_total", "counter", "CPU time spent in each mode, summed over all CPUs.")
+		for _, mode := range cpuModes {
+			if v, ok := cpu[mode]; ok {
+				p.sample("proxyhub_node_cpu_seconds_total", v, "mode", mode)
+			}
+		}
+	}
+
+	if load, err := systemCollector.LoadAvg(); err == nil {
+		p.metric("proxyhub_node_load1", "gauge", "1 minute load average.", load[0])
+		p.metric("proxyhub_node_load5", "gauge", "5 minute load average.", load[1])
+		p.metric("proxyhub_node_load15", "gauge", "15 minute load average.", load[2])
+	}
+
+	if mem, err := systemCollector.MemInfo(); err == nil {
+		p.metric("proxyhub_node_memory_total_bytes", "gauge", "Total usable memory.", float64(mem["MemTotal"]))
+		p.metric("proxyhub_node_memory_available_bytes", "gauge", "Memory available for new allocations.", float64(mem["MemAvailable"]))
+		p.metric("proxyhub_node_swap_total_bytes", "gauge", "Total swap space.", float64(mem["SwapTotal"]))
+		p.metric("proxyhub_node_swap_free_bytes", "gauge", "Unused swap space.", float64(mem["SwapFree"]))
+	}
+
+	if disk, err := systemCollector.Disk("/"); err == nil {
+		p.metric("proxyhub_node_filesystem_size_bytes", "gauge", "Filesystem size.", float64(disk.TotalBytes), "mountpoint", disk.Mountpoint)
+		p.metric("proxyhub_node_filesystem_avail_bytes", "gauge", "Filesystem space available to unprivileged users.", float64(disk.AvailableBytes), "mountpoint", disk.Mountpoint)
+	}
+
+	if uptime, err := systemCollector.Uptime(); err == nil {
+		p.metric("proxyhub_node_uptime_seconds", "gauge", "Seconds since the system booted.", uptime)
+	}
+}
+
+func nodeMetricsHandle(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	writeNodeMetrics(&promWriter{w: &buf})
+
+	w.Header().Set("Content-Type", metricsContentType)
+	w.Write(buf.Bytes())
+}
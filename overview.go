@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	overviewTTL     = 12 * time.Second
+	overviewWorkers = 8
+)
+
+var fleetOverview *FleetOverview
+
+type ServerOverview struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Location   string      `json:"location"`
+	OK         bool        `json:"ok"`
+	Stat       *Stat       `json:"stat,omitempty"`
+	Quota      *QuotaUsage `json:"quota,omitempty"`
+	QuotaError string      `json:"quotaError,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	FetchedAt  int64       `json:"fetchedAt"`
+}
+
+type Overview struct {
+	Servers     []ServerOverview `json:"servers"`
+	Totals      Stat             `json:"totals"`
+	Reporting   int              `json:"reporting"`
+	Failed      int              `json:"failed"`
+	GeneratedAt int64            `json:"generatedAt"`
+}
+
+type FleetOverview struct {
+	ttl        time.Duration
+	workers    int
+	client     *http.Client
+	mu         sync.Mutex
+	cached     *Overview
+	refreshing chan struct{}
+}
+
+func NewFleetOverview(ttl time.Duration, workers int, client *http.Client) *FleetOverview {
+	return &FleetOverview{
+		ttl:     ttl,
+		workers: workers,
+		client:  client,
+	}
+}
+
+// Get returns the last collected overview right away and refreshes a stale
+// one in the background, so slow info servers never hold up callers. Only
+// the very first call waits, and only until ctx is done.
+func (f *FleetOverview) Get(ctx context.Context) (*Overview, error) {
+	f.mu.Lock()
+	cached := f.cached
+	done := f.refreshing
+	stale := cached == nil || time.Since(time.Unix(cached.GeneratedAt, 0)) >= f.ttl
+	if stale && done == nil {
+		done = make(chan struct{})
+		f.refreshing = done
+		go f.refresh(serverRegistry.Servers(), done)
+	}
+	f.mu.Unlock()
+
+	if cached != nil {
+		return cached, nil
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cached, nil
+}
+
+func (f *FleetOverview) refresh(servers []*ProxyServerInfo, done chan struct{}) {
+	overview := f.collect(context.Background(), servers)
+
+	f.mu.Lock()
+	f.cached = overview
+	f.refreshing = nil
+	f.mu.Unlock()
+	close(done)
+}
+
+func (f *FleetOverview) collect(ctx context.Context, servers []*ProxyServerInfo) *Overview {
+	overview := &Overview{
+		Servers:     make([]ServerOverview, len(servers)),
+		GeneratedAt: time.Now().Unix(),
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(f.workers, len(servers)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				overview.Servers[i] = f.fetch(ctx, servers[i])
+			}
+		}()
+	}
+	for i := range servers {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, server := range overview.Servers {
+		if !server.OK {
+			overview.Failed++
+			continue
+		}
+		overview.Reporting++
+		overview.Totals.DayRx += server.Stat.DayRx
+		overview.Totals.DayTX += server.Stat.DayTX
+		overview.Totals.Day7Rx += server.Stat.Day7Rx
+		overview.Totals.Day7TX += server.Stat.Day7TX
+		overview.Totals.Day30Rx += server.Stat.Day30Rx
+		overview.Totals.Day30TX += server.Stat.Day30TX
+	}
+
+	return overview
+}
+
+func (f *FleetOverview) fetch(ctx context.Context, server *ProxyServerInfo) ServerOverview {
+	result := ServerOverview{
+		ID:        server.ID,
+		Name:      server.Name,
+		Location:  server.Location,
+		FetchedAt: time.Now().Unix(),
+	}
+
+	stat, err := f.fetchStat(ctx, server)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.OK = true
+	result.Stat = stat
+
+	if server.Limit.Enabled() {
+		days, err := f.fetchDays(ctx, server)
+		if err != nil {
+			result.QuotaError = err.Error()
+		} else {
+			result.Quota = ComputeQuotaUsage(server.Limit, days, time.Now())
+		}
+	}
+
+	return result
+}
+
+func (f *FleetOverview) fetchNodeJSON(ctx context.Context, infoLink, path string, query url.Values, v any) error {
+	nodeURL := strings.TrimSuffix(infoLink, "/") + path
+	if len(query) > 0 {
+		nodeURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nodeURL, nil)
+	if err != nil {
+		return err
+	}
+	signInfoRequest(req)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(v)
+}
+
+func (f *FleetOverview) fetchStat(ctx context.Context, server *ProxyServerInfo) (*Stat, error) {
+	query := url.Values{}
+	if server.Interface != "" {
+		query.Set("interface", server.Interface)
+	}
+
+	stat := new(Stat)
+	if err := f.fetchNodeJSON(ctx, server.InfoLink, "/stat", query, stat); err != nil {
+		return nil, err
+	}
+	return stat, nil
+}
+
+func (f *FleetOverview) fetchDays(ctx context.Context, server *ProxyServerInfo) ([]DayStats, error) {
+	query := url.Values{"mode": {"d"}, "limit": {"31"}}
+	if server.Interface != "" {
+		query.Set("interface", server.Interface)
+	}
+
+	var vnStat VnStatData
+	if err := f.fetchNodeJSON(ctx, server.InfoLink, "/rawstat", query, &vnStat); err != nil {
+		return nil, err
+	}
+	iface, err := selectInterface(&vnStat, "")
+	if err != nil {
+		return nil, err
+	}
+	return iface.Traffic.Day, nil
+}
+
+func overviewHandle(w http.ResponseWriter, r *http.Request) {
+	overview, err := fleetOverview.Get(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, http.StatusOK, overview)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newSlowInfoServer answers /stat only after release receives a value.
+func newSlowInfoServer(t *testing.T, release chan struct{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		json.NewEncoder(w).Encode(Stat{DayRx: 1, DayTX: 2})
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	return srv
+}
+
+func setupOverviewTest(t *testing.T, infoLink string) {
+	t.Helper()
+	prev := serverRegistry
+	t.Cleanup(func() { serverRegistry = prev })
+
+	serverRegistry = NewServerRegistry("")
+	serverRegistry.snapshot.Store(&ServerSnapshot{
+		Servers: []*ProxyServerInfo{{ID: "node", Name: "Node", InfoLink: infoLink}},
+	})
+}
+
+func TestFleetOverviewServesCachedWhileRefreshing(t *testing.T) {
+	release := make(chan struct{})
+	srv := newSlowInfoServer(t, release)
+	setupOverviewTest(t, srv.URL)
+	f := NewFleetOverview(time.Millisecond, 2, srv.Client())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := f.Get(ctx); err == nil {
+		t.Fatal("first Get() before any collection returned no error, want context error")
+	}
+
+	release <- struct{}{}
+	first, err := f.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Reporting != 1 || first.Totals.DayTX != 2 {
+		t.Fatalf("Get() = %+v, want one reporting server", first)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	start := time.Now()
+	for range 3 {
+		got, err := f.Get(context.Background())
+		if err != nil || got != first {
+			t.Fatalf("stale Get() = %p, %v; want cached %p", got, err, first)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("stale Get() blocked for %v behind the refresh", elapsed)
+	}
+
+	release <- struct{}{}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, _ := f.Get(context.Background())
+		if got != first {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background refresh never replaced the cached overview")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var endpointProber *EndpointProber
+
+type ProbeCheck struct {
+	OK        bool   `json:"ok"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+type LinkProbe struct {
+	ServerID  string       `json:"serverId"`
+	Protocol  string       `json:"protocol"`
+	Index     int          `json:"index"`
+	Address   string       `json:"address"`
+	Skipped   string       `json:"skipped,omitempty"`
+	TCP       *ProbeCheck  `json:"tcp,omitempty"`
+	TLS       *ProbeCheck  `json:"tls,omitempty"`
+	Tunnel    *TunnelCheck `json:"tunnel,omitempty"`
+	CheckedAt int64        `json:"checkedAt"`
+}
+
+type EndpointProber struct {
+	interval    time.Duration
+	timeout     time.Duration
+	target      string
+	concurrency int
+	mu          sync.RWMutex
+	results     []LinkProbe
+}
+
+type probeTarget struct {
+	serverID string
+	protocol string
+	index    int
+	link     *ParsedLink
+}
+
+func NewEndpointProber(interval, timeout time.Duration, concurrency int, target string) *EndpointProber {
+	return &EndpointProber{
+		interval:    interval,
+		timeout:     timeout,
+		target:      target,
+		concurrency: concurrency,
+	}
+}
+
+func (p *EndpointProber) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.probeAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *EndpointProber) probeAll(ctx context.Context) {
+	var targets []probeTarget
+	for _, server := range serverRegistry.Servers() {
+		server.ProxyLinks.Each(func(protocol *ProxyProtocol, index int, raw string) {
+			link, err := protocol.Parse(raw)
+			if err != nil {
+				return
+			}
+			targets = append(targets, probeTarget{server.ID, protocol.Key, index, link})
+		})
+	}
+
+	results := make([]LinkProbe, len(targets))
+	sem := make(chan struct{}, p.concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = p.probe(ctx, target)
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	p.mu.Lock()
+	p.results = results
+	p.mu.Unlock()
+}
+
+func (p *EndpointProber) probe(ctx context.Context, target probeTarget) LinkProbe {
+	link := target.link
+	result := LinkProbe{
+		ServerID:  target.serverID,
+		Protocol:  target.protocol,
+		Index:     target.index,
+		Address:   link.Address(),
+		CheckedAt: time.Now().Unix(),
+	}
+
+	switch link.Protocol {
+	case "hysteria2", "tuic", "wireguard":
+		result.Skipped = "udp transport"
+		return result
+	}
+
+	dialer := &net.Dialer{Timeout: p.timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", link.Address())
+	result.TCP = &ProbeCheck{OK: err == nil, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.TCP.Error = err.Error()
+		return result
+	}
+
+	if tlsConfig := probeTLSConfig(link); tlsConfig != nil {
+		result.TLS = p.handshake(ctx, conn, tlsConfig)
+	}
+	conn.Close()
+
+	if p.target != "" && (link.Protocol == "http" || link.Protocol == "socks") {
+		result.Tunnel = testTunnel(ctx, link, p.target, 4*p.timeout)
+	}
+
+	return result
+}
+
+func (p *EndpointProber) handshake(ctx context.Context, conn net.Conn, config *tls.Config) *ProbeCheck {
+	handshakeCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := tls.Client(conn, config).HandshakeContext(handshakeCtx)
+	check := &ProbeCheck{OK: err == nil, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
+
+func probeTLSConfig(link *ParsedLink) *tls.Config {
+	security := link.Params.Get("security")
+	switch {
+	case link.Protocol == "http" && link.Scheme == "https":
+		return &tls.Config{ServerName: link.Host}
+	case link.Protocol == "vless" && security != "tls" && security != "reality":
+		return nil
+	case link.Protocol != "vless" && link.Protocol != "trojan":
+		return nil
+	}
+
+	serverName := link.Params.Get("sni")
+	if serverName == "" {
+		serverName = link.Host
+	}
+	return &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: security == "reality" || isTrueParam(link.Params.Get("allowInsecure")),
+	}
+}
+
+func (p *EndpointProber) Results(serverID string) []LinkProbe {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := []LinkProbe{}
+	for _, probe := range p.results {
+		if serverID == "" || probe.ServerID == serverID {
+			result = append(result, probe)
+		}
+	}
+	return result
+}
+
+func probeHandle(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, endpointProber.Results(r.URL.Query().Get("id")))
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncodeQRCodeCapacity(t *testing.T) {
+	tests := []struct {
+		length int
+		size   int
+	}{
+		{0, 21},
+		{14, 21},
+		{15, 25},
+		{26, 25},
+		{27, 29},
+		{2331, 177},
+	}
+	for _, tc := range tests {
+		qr, err := EncodeQRCode(bytes.Repeat([]byte{'a'}, tc.length))
+		if err != nil {
+			t.Errorf("EncodeQRCode(%d bytes) error: %v", tc.length, err)
+			continue
+		}
+		if qr.Size != tc.size {
+			t.Errorf("EncodeQRCode(%d bytes).Size = %d, want %d", tc.length, qr.Size, tc.size)
+		}
+	}
+
+	if _, err := EncodeQRCode(bytes.Repeat([]byte{'a'}, 2332)); err == nil {
+		t.Error("EncodeQRCode(2332 bytes) succeeded, want error")
+	}
+}
+
+func TestQRReedSolomon(t *testing.T) {
+	// 1-M "HELLO WORLD" from the ISO 18004 worked example.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := qrReedSolomonRemainder(data, qrReedSolomonDivisor(len(want)))
+	if !bytes.Equal(got, want) {
+		t.Errorf("qrReedSolomonRemainder() = %v, want %v", got, want)
+	}
+}
+
+// readQRFormatBits reads both copies of the 15-bit format information.
+func readQRFormatBits(qr *QRCode) (int, int) {
+	first, second := 0, 0
+	set := func(v *int, i int, dark bool) {
+		if dark {
+			*v |= 1 << i
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		set(&first, i, qr.modules[i][8])
+	}
+	set(&first, 6, qr.modules[7][8])
+	set(&first, 7, qr.modules[8][8])
+	set(&first, 8, qr.modules[8][7])
+	for i := 9; i < 15; i++ {
+		set(&first, i, qr.modules[8][14-i])
+	}
+	for i := 0; i < 8; i++ {
+		set(&second, i, qr.modules[8][qr.Size-1-i])
+	}
+	for i := 8; i < 15; i++ {
+		set(&second, i, qr.modules[qr.Size-15+i][8])
+	}
+	return first, second
+}
+
+func TestQRFormatBits(t *testing.T) {
+	// Format strings for error correction level M, masks 0-7.
+	want := []int{
+		0b101010000010010,
+		0b101000100100101,
+		0b101111001111100,
+		0b101101101001011,
+		0b100010111111001,
+		0b100000011001110,
+		0b100111110010111,
+		0b100101010100000,
+	}
+	for mask, bits := range want {
+		qr := newQRCode(1)
+		qr.drawFormatBits(mask)
+		first, second := readQRFormatBits(qr)
+		if first != bits || second != bits {
+			t.Errorf("mask %d: format bits %015b / %015b, want %015b", mask, first, second, bits)
+		}
+		if !qr.modules[qr.Size-8][8] {
+			t.Errorf("mask %d: dark module not set", mask)
+		}
+	}
+}
+
+func qrMatrixText(qr *QRCode) string {
+	var sb strings.Builder
+	for _, row := range qr.modules {
+		for _, dark := range row {
+			if dark {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// decodeQRBytes decodes a single-block byte-mode symbol, checking the ECC.
+func decodeQRBytes(t *testing.T, matrix string, version int) []byte {
+	t.Helper()
+
+	qr := newQRCode(version)
+	qr.drawFunctionPatterns(version)
+	for y, row := range strings.Split(strings.TrimSuffix(matrix, "\n"), "\n") {
+		for x, c := range row {
+			qr.modules[y][x] = c == '#'
+		}
+	}
+
+	format, _ := readQRFormatBits(qr)
+	format ^= 0x5412
+	if level := format >> 13; level != 0 {
+		t.Fatalf("error correction level bits %02b, want M (00)", level)
+	}
+	mask := (format >> 10) & 7
+	qr.applyMask(mask)
+
+	var raw []byte
+	var cur byte
+	n := 0
+	for right := qr.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := range qr.Size {
+			for j := range 2 {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = qr.Size - 1 - vert
+				}
+				if qr.isFunction[y][x] {
+					continue
+				}
+				cur <<= 1
+				if qr.modules[y][x] {
+					cur |= 1
+				}
+				if n++; n%8 == 0 {
+					raw = append(raw, cur)
+				}
+			}
+		}
+	}
+
+	dataLen := qrNumDataCodewords(version)
+	eccLen := qrECCCodewordsPerBlock[version]
+	data, ecc := raw[:dataLen], raw[dataLen:dataLen+eccLen]
+	if got := qrReedSolomonRemainder(data, qrReedSolomonDivisor(eccLen)); !bytes.Equal(got, ecc) {
+		t.Fatalf("ECC mismatch: computed %v, stored %v", got, ecc)
+	}
+
+	if mode := data[0] >> 4; mode != 0x4 {
+		t.Fatalf("mode %x, want byte mode", mode)
+	}
+	length := int(data[0]&0x0F)<<4 | int(data[1]>>4)
+	result := make([]byte, length)
+	for i := range length {
+		result[i] = data[1+i]<<4 | data[2+i]>>4
+	}
+	return result
+}
+
+func TestEncodeQRCodeGolden(t *testing.T) {
+	content := "https://example.com/sub"
+	qr, err := EncodeQRCode([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qr.Size != 25 {
+		t.Fatalf("Size = %d, want 25 (version 2)", qr.Size)
+	}
+
+	matrix := qrMatrixText(qr)
+	checkGolden(t, filepath.Join("testdata", "qrcode", "example_sub.txt"), []byte(matrix))
+
+	if got := decodeQRBytes(t, matrix, 2); string(got) != content {
+		t.Errorf("decoded %q, want %q", got, content)
+	}
+}
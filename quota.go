@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+const (
+	QuotaRx   = "rx"
+	QuotaTx   = "tx"
+	QuotaBoth = "both"
+)
+
+type TrafficLimit struct {
+	Bytes     uint64 `json:"bytes"`
+	ResetDay  int    `json:"resetDay"`
+	Direction string `json:"direction"`
+	Display   string `json:"display,omitempty"`
+
+	legacy bool
+}
+
+type QuotaUsage struct {
+	LimitBytes          uint64  `json:"limitBytes"`
+	Direction           string  `json:"direction"`
+	ResetDay            int     `json:"resetDay"`
+	CycleStart          int64   `json:"cycleStart"`
+	CycleEnd            int64   `json:"cycleEnd"`
+	UsedBytes           uint64  `json:"usedBytes"`
+	UsedRxBytes         uint64  `json:"usedRxBytes"`
+	UsedTxBytes         uint64  `json:"usedTxBytes"`
+	RemainingBytes      uint64  `json:"remainingBytes"`
+	Percent             float64 `json:"percent"`
+	ProjectedExhaustion *int64  `json:"projectedExhaustion"`
+}
+
+type ServerQuota struct {
+	ID    string      `json:"id"`
+	Name  string      `json:"name"`
+	Limit string      `json:"limit"`
+	Quota *QuotaUsage `json:"quota"`
+	Error string      `json:"error,omitempty"`
+}
+
+func (l *TrafficLimit) UnmarshalJSON(data []byte) error {
+	var display string
+	if err := json.Unmarshal(data, &display); err == nil {
+		*l = TrafficLimit{Display: display, legacy: true}
+		return nil
+	}
+
+	type plain TrafficLimit
+	var v plain
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("limit: %w", err)
+	}
+	*l = TrafficLimit(v)
+	if l.ResetDay == 0 {
+		l.ResetDay = 1
+	}
+	if l.Direction == "" {
+		l.Direction = QuotaBoth
+	}
+	return nil
+}
+
+// Legacy string limits are display-only and written back unchanged.
+func (l TrafficLimit) MarshalJSON() ([]byte, error) {
+	if l.legacy {
+		return json.Marshal(l.Display)
+	}
+	type plain TrafficLimit
+	return json.Marshal(plain(l))
+}
+
+func (l *TrafficLimit) Enabled() bool {
+	return l != nil && !l.legacy && l.Bytes > 0
+}
+
+func (l *TrafficLimit) Validate() error {
+	if l.legacy {
+		return nil
+	}
+	if l.Bytes == 0 {
+		return fmt.Errorf("bytes must be greater than 0")
+	}
+	if l.ResetDay < 1 || l.ResetDay > 31 {
+		return fmt.Errorf("resetDay must be between 1 and 31")
+	}
+	switch l.Direction {
+	case QuotaRx, QuotaTx, QuotaBoth:
+	default:
+		return fmt.Errorf("direction must be one of rx, tx, both")
+	}
+	return nil
+}
+
+func (l *TrafficLimit) String() string {
+	if l == nil {
+		return ""
+	}
+	if l.Display != "" {
+		return l.Display
+	}
+	if l.Bytes == 0 {
+		return ""
+	}
+	return formatBytesIEC(l.Bytes)
+}
+
+func cycleDate(year int, month time.Month, resetDay int, loc *time.Location) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	return time.Date(year, month, min(resetDay, lastDay), 0, 0, 0, 0, loc)
+}
+
+func quotaCycle(now time.Time, resetDay int) (time.Time, time.Time) {
+	start := cycleDate(now.Year(), now.Month(), resetDay, now.Location())
+	if now.Before(start) {
+		start = cycleDate(now.Year(), now.Month()-1, resetDay, now.Location())
+	}
+	end := cycleDate(start.Year(), start.Month()+1, resetDay, now.Location())
+	return start, end
+}
+
+func ComputeQuotaUsage(limit *TrafficLimit, days []DayStats, now time.Time) *QuotaUsage {
+	start, end := quotaCycle(now, limit.ResetDay)
+	usage := &QuotaUsage{
+		LimitBytes: limit.Bytes,
+		Direction:  limit.Direction,
+		ResetDay:   limit.ResetDay,
+		CycleStart: start.Unix(),
+		CycleEnd:   end.Unix(),
+	}
+
+	for _, day := range days {
+		date := time.Date(day.Date.Year, time.Month(day.Date.Month), day.Date.Day, 0, 0, 0, 0, now.Location())
+		if date.Before(start) || !date.Before(end) {
+			continue
+		}
+		if limit.Direction != QuotaTx {
+			usage.UsedRxBytes += day.Rx
+		}
+		if limit.Direction != QuotaRx {
+			usage.UsedTxBytes += day.Tx
+		}
+	}
+	usage.UsedBytes = usage.UsedRxBytes + usage.UsedTxBytes
+
+	if usage.UsedBytes < limit.Bytes {
+		usage.RemainingBytes = limit.Bytes - usage.UsedBytes
+	}
+	usage.Percent = math.Round(float64(usage.UsedBytes)/float64(limit.Bytes)*10000) / 100
+
+	elapsed := now.Sub(start)
+	if usage.UsedBytes == 0 || elapsed <= 0 {
+		return usage
+	}
+	if usage.RemainingBytes == 0 {
+		exhausted := now.Unix()
+		usage.ProjectedExhaustion = &exhausted
+		return usage
+	}
+	rate := float64(usage.UsedBytes) / elapsed.Seconds()
+	exhausted := now.Add(time.Duration(float64(usage.RemainingBytes)/rate) * time.Second)
+	if exhausted.Before(end) {
+		ts := exhausted.Unix()
+		usage.ProjectedExhaustion = &ts
+	}
+
+	return usage
+}
+
+func quotaHandle(w http.ResponseWriter, r *http.Request) {
+	overview, err := fleetOverview.Get(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	servers := serverRegistry.Servers()
+
+	result := make([]ServerQuota, 0, len(servers))
+	for _, server := range servers {
+		if !server.Limit.Enabled() {
+			continue
+		}
+		entry := ServerQuota{ID: server.ID, Name: server.Name, Limit: server.Limit.String()}
+		for _, o := range overview.Servers {
+			if o.ID != server.ID {
+				continue
+			}
+			entry.Quota = o.Quota
+			entry.Error = cmp.Or(o.Error, o.QuotaError)
+		}
+		result = append(result, entry)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const proxyServersFile = "proxyservers.json"
+
+var serverRegistry *ServerRegistry
+
+type ServerRegistry struct {
+	path     string
+	writeMu  sync.Mutex
+	snapshot atomic.Pointer[ServerSnapshot]
+}
+
+type ServerSnapshot struct {
+	Servers  []*ProxyServerInfo
+	Raw      []byte
+	LoadedAt time.Time
+}
+
+func NewServerRegistry(path string) *ServerRegistry {
+	return &ServerRegistry{path: path}
+}
+
+func (r *ServerRegistry) Snapshot() *ServerSnapshot {
+	if s := r.snapshot.Load(); s != nil {
+		return s
+	}
+	return &ServerSnapshot{}
+}
+
+func (r *ServerRegistry) Servers() []*ProxyServerInfo {
+	return r.Snapshot().Servers
+}
+
+func (r *ServerRegistry) Find(id string) *ProxyServerInfo {
+	for _, s := range r.Servers() {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func (r *ServerRegistry) Load() error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	content, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", r.path, err)
+	}
+
+	servers, err := ValidateProxyServers(content)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", r.path, err)
+	}
+
+	r.snapshot.Store(&ServerSnapshot{
+		Servers:  servers,
+		Raw:      content,
+		LoadedAt: time.Now(),
+	})
+
+	return nil
+}
+
+func (r *ServerRegistry) Update(mutate func(servers []*ProxyServerInfo) ([]*ProxyServerInfo, error)) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	servers, _ := ValidateProxyServers(r.Snapshot().Raw)
+
+	servers, err := mutate(servers)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(servers, "", "    ")
+	if err != nil {
+		return err
+	}
+	content = append(content, '\n')
+
+	servers, err = ValidateProxyServers(content)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(r.path, content, 0644); err != nil {
+		return err
+	}
+
+	r.snapshot.Store(&ServerSnapshot{
+		Servers:  servers,
+		Raw:      content,
+		LoadedAt: time.Now(),
+	})
+
+	return nil
+}
+
+func (r *ServerRegistry) Watch(ctx context.Context) {
+	changes := make(chan struct{}, 1)
+
+	go func() {
+		if err := watchFile(ctx, r.path, changes); err != nil {
+			log.Printf("Server registry watch error: %v", err)
+		}
+	}()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var debounce <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload("SIGHUP")
+		case <-changes:
+			debounce = time.After(250 * time.Millisecond)
+		case <-debounce:
+			debounce = nil
+			r.reload("file change")
+		}
+	}
+}
+
+func (r *ServerRegistry) reload(reason string) {
+	if err := r.Load(); err != nil {
+		log.Printf("Server registry reload (%s) rejected, keeping last good snapshot: %v", reason, err)
+		return
+	}
+	log.Printf("Server registry reloaded (%s): %d servers", reason, len(r.Servers()))
+}
+
+func notifyChange(changes chan<- struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
@@ -5,12 +5,14 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"slices"
@@ -19,35 +21,53 @@ import (
 )
 
 var serverFullExternalURL string
-var proxyServersInfo []*ProxyServerInfo
 
 type ServerParams struct {
-	Dir     string
-	Host    string
-	Port    int
-	Proto   string
-	KeyFile string
-	CrtFile string
-	Prefix  string
+	Dir        string
+	Host       string
+	Port       int
+	Proto      string
+	KeyFile    string
+	CrtFile    string
+	Prefix     string
+	AdminToken string
+	InfoSecret string
+	InfoTLS    InfoTLSParams
+	Health     time.Duration
+	Probe      time.Duration
+	ProbeURL   string
+	UptimeFile string
+
+	QuotaAlerts    []float64
+	QuotaAlertFile string
+
+	MetricsHost string
+	MetricsPort int
 }
 
 type ProxyServerInfo struct {
-	Name         string     `json:"name"`
-	ID           string     `json:"id"`
-	Location     string     `json:"location"`
-	ProviderName string     `json:"providerName"`
-	ProviderLink string     `json:"providerLink"`
-	Plan         string     `json:"plan"`
-	SpeedRate    string     `json:"speedRate"`
-	Limit        string     `json:"limit"`
-	InfoLink     string     `json:"infoLink"`
-	ProxyLinks   ProxyLinks `json:"proxyLinks"`
+	Name         string        `json:"name"`
+	ID           string        `json:"id"`
+	Location     string        `json:"location"`
+	ProviderName string        `json:"providerName"`
+	ProviderLink string        `json:"providerLink"`
+	Plan         string        `json:"plan"`
+	SpeedRate    string        `json:"speedRate"`
+	Limit        *TrafficLimit `json:"limit,omitempty"`
+	InfoLink     string        `json:"infoLink"`
+	Interface    string        `json:"interface,omitempty"`
+	ProxyLinks   ProxyLinks    `json:"proxyLinks"`
 }
 
 type ProxyLinks struct {
-	Vless []string `json:"vless"`
-	HTTP  []string `json:"http"`
-	Socks []string `json:"socks"`
+	Vless       []string `json:"vless"`
+	HTTP        []string `json:"http"`
+	Socks       []string `json:"socks"`
+	Trojan      []string `json:"trojan,omitempty"`
+	Shadowsocks []string `json:"ss,omitempty"`
+	Hysteria2   []string `json:"hysteria2,omitempty"`
+	TUIC        []string `json:"tuic,omitempty"`
+	WireGuard   []string `json:"wireguard,omitempty"`
 }
 
 func randomKey() string {
@@ -81,36 +101,53 @@ func encrypt(key []byte, text string) (string, error) {
 }
 
 func serverInfoHandle(w http.ResponseWriter, r *http.Request) {
-	url := r.URL.Query().Get("url")
-	if url == "" {
+	rawURL := r.URL.Query().Get("url")
+	if rawURL == "" {
 		http.Error(w, "url parameter is required", http.StatusBadRequest)
 		return
 	}
+	target, err := url.Parse(rawURL)
+	if err != nil || target.User != nil {
+		http.Error(w, "invalid url", http.StatusBadRequest)
+		return
+	}
 
-	hasServer := slices.ContainsFunc(proxyServersInfo, func(e *ProxyServerInfo) bool {
-		return strings.HasPrefix(url, e.InfoLink)
+	servers := serverRegistry.Servers()
+	i := slices.IndexFunc(servers, func(e *ProxyServerInfo) bool {
+		info, err := url.Parse(e.InfoLink)
+		return err == nil && strings.EqualFold(info.Scheme, target.Scheme) && strings.EqualFold(info.Host, target.Host)
 	})
 
-	if !hasServer {
+	if i < 0 {
 		http.Error(w, "server not found", http.StatusBadRequest)
 		return
 	}
+	infoLink := servers[i].InfoLink
 
-	client := &http.Client{
-		Timeout: 4 * time.Second,
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, target.String(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	signInfoRequest(req)
 
-	resp, err := client.Get(url)
+	resp, err := infoClient.Do(req)
 	if err != nil {
 		if os.IsTimeout(err) {
+			hubMetrics.UpstreamError(infoLink, "timeout")
 			http.Error(w, "Request timeout", http.StatusGatewayTimeout)
 		} else {
+			hubMetrics.UpstreamError(infoLink, "error")
 			http.Error(w, err.Error(), http.StatusBadGateway)
 		}
 		return
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= 500 {
+		hubMetrics.UpstreamError(infoLink, "status")
+	}
+
 	for key, values := range resp.Header {
 		for _, value := range values {
 			w.Header().Add(key, value)
@@ -126,11 +163,7 @@ func serverInfoHandle(w http.ResponseWriter, r *http.Request) {
 }
 
 func proxyServersInfoHandle(w http.ResponseWriter, r *http.Request) {
-	data, err := os.ReadFile("proxyservers.json")
-	if err != nil {
-		http.Error(w, "", http.StatusInternalServerError)
-		return
-	}
+	data := serverRegistry.Snapshot().Raw
 
 	ekey := randomKey()
 	encryptData, err := encrypt([]byte(ekey), string(data))
@@ -145,20 +178,53 @@ func proxyServersInfoHandle(w http.ResponseWriter, r *http.Request) {
 func RunServer(ctx context.Context, stop context.CancelFunc, params *ServerParams) {
 	defer stop()
 
-	if _, err := os.Stat("proxyservers.json"); os.IsNotExist(err) {
-		log.Fatal("proxyservers.json not found")
+	if _, err := os.Stat(proxyServersFile); os.IsNotExist(err) {
+		log.Fatalf("%s not found", proxyServersFile)
 	}
 
-	content, err := os.ReadFile("proxyservers.json")
-	if err != nil {
-		log.Fatalf("read proxyservers.json error: %v", err)
+	infoSharedSecret = []byte(params.InfoSecret)
+	var infoTLS *tls.Config
+	if params.InfoTLS.Enabled() {
+		var err error
+		infoTLS, err = infoHubTLSConfig(&params.InfoTLS)
+		if err != nil {
+			log.Fatalf("info client TLS error: %v", err)
+		}
 	}
+	infoClient = newInfoClient(4*time.Second, infoTLS)
 
-	err = json.Unmarshal(content, &proxyServersInfo)
+	serverRegistry = NewServerRegistry(proxyServersFile)
+	if err := serverRegistry.Load(); err != nil {
+		log.Fatalf("server registry error: %v", err)
+	}
+	go serverRegistry.Watch(ctx)
+
+	var err error
+	uptimeStore, err = NewUptimeStore(params.UptimeFile)
 	if err != nil {
-		log.Fatalf("unmarshal proxyservers.json error: %v", err)
+		log.Fatalf("uptime history error: %v", err)
 	}
 
+	eventStream = NewEventStream()
+
+	healthChecker = NewHealthChecker(params.Health, infoClient)
+	go healthChecker.Run(ctx)
+
+	endpointProber = NewEndpointProber(params.Probe, 5*time.Second, 16, params.ProbeURL)
+	go endpointProber.Run(ctx)
+
+	fleetOverview = NewFleetOverview(overviewTTL, overviewWorkers, infoClient)
+
+	if len(params.QuotaAlerts) > 0 {
+		quotaAlerter, err = NewQuotaAlerter(params.QuotaAlertFile, params.QuotaAlerts, NotifyOwner)
+		if err != nil {
+			log.Fatalf("quota alert state error: %v", err)
+		}
+		go quotaAlerter.Run(ctx)
+	}
+
+	go eventStream.Run(ctx, overviewTTL)
+
 	mux := http.NewServeMux()
 
 	mux.HandleFunc(params.Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
@@ -174,11 +240,38 @@ func RunServer(ctx context.Context, stop context.CancelFunc, params *ServerParam
 
 	mux.HandleFunc(params.Prefix+"/proxyservers", proxyServersInfoHandle)
 
+	mux.HandleFunc(params.Prefix+"/sub", subscriptionHandle)
+
+	mux.HandleFunc(params.Prefix+"/clash", clashProfileHandle)
+
+	mux.HandleFunc(params.Prefix+"/singbox", singBoxConfigHandle)
+
+	mux.HandleFunc(params.Prefix+"/qr", qrCodeHandle)
+
+	mux.HandleFunc(params.Prefix+"/api/health", healthHandle)
+
+	mux.HandleFunc(params.Prefix+"/api/uptime", uptimeHandle)
+
+	mux.HandleFunc(params.Prefix+"/api/probe", probeHandle)
+
+	mux.HandleFunc(params.Prefix+"/api/overview", overviewHandle)
+
+	mux.HandleFunc(params.Prefix+"/api/quota", quotaHandle)
+
+	mux.HandleFunc(params.Prefix+"/api/stream", streamHandle)
+
 	mux.HandleFunc(params.Prefix+"/pubvars", func(w http.ResponseWriter, r *http.Request) {
 		data, _ := json.Marshal(&PubVars)
 		fmt.Fprint(w, string(data))
 	})
 
+	if params.AdminToken != "" {
+		adminToken = params.AdminToken
+		registerAdminHandlers(mux, params.Prefix)
+	} else {
+		log.Println("ADMIN_API_TOKEN not set, admin API disabled")
+	}
+
 	assetsPath := filepath.Join(params.Dir, "assets")
 	fs := http.FileServer(http.Dir(assetsPath))
 	mux.Handle(params.Prefix+"/assets/", http.StripPrefix(params.Prefix+"/assets/", fs))
@@ -186,7 +279,16 @@ func RunServer(ctx context.Context, stop context.CancelFunc, params *ServerParam
 	addr := fmt.Sprintf("%s:%d", params.Host, params.Port)
 	server := &http.Server{
 		Addr:    addr,
-		Handler: mux,
+		Handler: instrumentHandler(params.Prefix, mux),
+	}
+
+	if params.MetricsPort > 0 {
+		go RunMetricsServer(ctx, &MetricsServerParams{
+			Name:    "Hub metrics",
+			Host:    params.MetricsHost,
+			Port:    params.MetricsPort,
+			Handler: hubMetricsHandle,
+		})
 	}
 
 	log.Printf("Server running [LOCAL] at %s://127.0.0.1:%d%s\n", params.Proto, params.Port, params.Prefix)
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	signatureTimestampHeader = "X-ProxyHub-Timestamp"
+	signatureNonceHeader     = "X-ProxyHub-Nonce"
+	signatureHeader          = "X-ProxyHub-Signature"
+	signatureMaxSkew         = 2 * time.Minute
+)
+
+var infoSharedSecret []byte
+
+func requestSignature(secret []byte, timestamp, nonce, method, host, uri string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "\n" + nonce + "\n" + method + "\n" + strings.ToLower(host) + "\n" + uri))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func signInfoRequest(req *http.Request) {
+	if len(infoSharedSecret) == 0 {
+		return
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonceHex := hex.EncodeToString(nonce)
+
+	req.Header.Set(signatureTimestampHeader, timestamp)
+	req.Header.Set(signatureNonceHeader, nonceHex)
+	req.Header.Set(signatureHeader, requestSignature(infoSharedSecret, timestamp, nonceHex, req.Method, req.URL.Host, req.URL.RequestURI()))
+}
+
+type SignatureVerifier struct {
+	secret []byte
+	mu     sync.Mutex
+	nonces map[string]int64
+}
+
+func NewSignatureVerifier(secret []byte) *SignatureVerifier {
+	return &SignatureVerifier{
+		secret: secret,
+		nonces: make(map[string]int64),
+	}
+}
+
+func (v *SignatureVerifier) verify(r *http.Request, now time.Time) (int, string) {
+	timestamp := r.Header.Get(signatureTimestampHeader)
+	nonce := r.Header.Get(signatureNonceHeader)
+	signature := r.Header.Get(signatureHeader)
+	if timestamp == "" || nonce == "" || signature == "" {
+		return http.StatusUnauthorized, "missing signature"
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return http.StatusUnauthorized, "invalid timestamp"
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > signatureMaxSkew || skew < -signatureMaxSkew {
+		return http.StatusUnauthorized, "timestamp out of range"
+	}
+
+	expected := requestSignature(v.secret, timestamp, nonce, r.Method, r.Host, r.URL.RequestURI())
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return http.StatusUnauthorized, "invalid signature"
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	cutoff := now.Add(-2 * signatureMaxSkew).Unix()
+	for n, seen := range v.nonces {
+		if seen < cutoff {
+			delete(v.nonces, n)
+		}
+	}
+	if _, ok := v.nonces[nonce]; ok {
+		return http.StatusUnauthorized, "replayed request"
+	}
+	v.nonces[nonce] = now.Unix()
+
+	return 0, ""
+}
+
+func (v *SignatureVerifier) Require(next http.HandlerFunc) http.HandlerFunc {
+	if v == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if status, msg := v.verify(r, time.Now()); status != 0 {
+			http.Error(w, msg, status)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const singBoxSelectorTag = "proxy"
+const singBoxURLTestTag = "auto"
+const singBoxDirectTag = "direct"
+
+type SingBoxConfig struct {
+	Log       SingBoxLog        `json:"log"`
+	Inbounds  []SingBoxInbound  `json:"inbounds"`
+	Outbounds []SingBoxOutbound `json:"outbounds"`
+	Endpoints []SingBoxEndpoint `json:"endpoints,omitempty"`
+	Route     SingBoxRoute      `json:"route"`
+}
+
+type SingBoxLog struct {
+	Level string `json:"level"`
+}
+
+type SingBoxInbound struct {
+	Type       string `json:"type"`
+	Tag        string `json:"tag"`
+	Listen     string `json:"listen"`
+	ListenPort int    `json:"listen_port"`
+}
+
+type SingBoxOutbound struct {
+	Type              string            `json:"type"`
+	Tag               string            `json:"tag"`
+	Server            string            `json:"server,omitempty"`
+	ServerPort        int               `json:"server_port,omitempty"`
+	Version           string            `json:"version,omitempty"`
+	UUID              string            `json:"uuid,omitempty"`
+	Flow              string            `json:"flow,omitempty"`
+	Username          string            `json:"username,omitempty"`
+	Password          string            `json:"password,omitempty"`
+	Method            string            `json:"method,omitempty"`
+	CongestionControl string            `json:"congestion_control,omitempty"`
+	UDPRelayMode      string            `json:"udp_relay_mode,omitempty"`
+	Obfs              *SingBoxObfs      `json:"obfs,omitempty"`
+	TLS               *SingBoxTLS       `json:"tls,omitempty"`
+	Transport         *SingBoxTransport `json:"transport,omitempty"`
+	Outbounds         []string          `json:"outbounds,omitempty"`
+	Default           string            `json:"default,omitempty"`
+	URL               string            `json:"url,omitempty"`
+	Interval          string            `json:"interval,omitempty"`
+	Tolerance         int               `json:"tolerance,omitempty"`
+}
+
+type SingBoxObfs struct {
+	Type     string `json:"type"`
+	Password string `json:"password"`
+}
+
+type SingBoxTLS struct {
+	Enabled    bool            `json:"enabled"`
+	ServerName string          `json:"server_name,omitempty"`
+	Insecure   bool            `json:"insecure,omitempty"`
+	ALPN       []string        `json:"alpn,omitempty"`
+	UTLS       *SingBoxUTLS    `json:"utls,omitempty"`
+	Reality    *SingBoxReality `json:"reality,omitempty"`
+}
+
+type SingBoxUTLS struct {
+	Enabled     bool   `json:"enabled"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+type SingBoxReality struct {
+	Enabled   bool   `json:"enabled"`
+	PublicKey string `json:"public_key"`
+	ShortID   string `json:"short_id,omitempty"`
+}
+
+type SingBoxTransport struct {
+	Type        string            `json:"type"`
+	Path        string            `json:"path,omitempty"`
+	Host        []string          `json:"host,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	ServiceName string            `json:"service_name,omitempty"`
+}
+
+type SingBoxEndpoint struct {
+	Type       string                 `json:"type"`
+	Tag        string                 `json:"tag"`
+	Address    []string               `json:"address"`
+	PrivateKey string                 `json:"private_key"`
+	MTU        int                    `json:"mtu,omitempty"`
+	Peers      []SingBoxWireGuardPeer `json:"peers"`
+}
+
+type SingBoxWireGuardPeer struct {
+	Address      string   `json:"address"`
+	Port         int      `json:"port"`
+	PublicKey    string   `json:"public_key"`
+	PreSharedKey string   `json:"pre_shared_key,omitempty"`
+	AllowedIPs   []string `json:"allowed_ips"`
+}
+
+type SingBoxRoute struct {
+	Rules               []SingBoxRouteRule `json:"rules"`
+	Final               string             `json:"final"`
+	AutoDetectInterface bool               `json:"auto_detect_interface"`
+}
+
+type SingBoxRouteRule struct {
+	IPIsPrivate bool   `json:"ip_is_private,omitempty"`
+	Outbound    string `json:"outbound"`
+}
+
+func singBoxTLS(link *ParsedLink) *SingBoxTLS {
+	tls := &SingBoxTLS{
+		Enabled:    true,
+		ServerName: link.Params.Get("sni"),
+		Insecure:   isTrueParam(link.Params.Get("allowInsecure")) || isTrueParam(link.Params.Get("insecure")),
+	}
+	if alpn := link.Params.Get("alpn"); alpn != "" {
+		tls.ALPN = strings.Split(alpn, ",")
+	}
+	if fp := link.Params.Get("fp"); fp != "" {
+		tls.UTLS = &SingBoxUTLS{Enabled: true, Fingerprint: fp}
+	}
+	if link.Params.Get("security") == "reality" {
+		tls.Reality = &SingBoxReality{
+			Enabled:   true,
+			PublicKey: link.Params.Get("pbk"),
+			ShortID:   link.Params.Get("sid"),
+		}
+		if tls.UTLS == nil {
+			tls.UTLS = &SingBoxUTLS{Enabled: true, Fingerprint: "chrome"}
+		}
+	}
+	return tls
+}
+
+func singBoxTransport(link *ParsedLink) *SingBoxTransport {
+	switch network := link.Params.Get("type"); network {
+	case "", "tcp":
+		return nil
+	case "ws":
+		transport := &SingBoxTransport{Type: "ws", Path: link.Params.Get("path")}
+		if host := link.Params.Get("host"); host != "" {
+			transport.Headers = map[string]string{"Host": host}
+		}
+		return transport
+	case "grpc":
+		return &SingBoxTransport{Type: "grpc", ServiceName: link.Params.Get("serviceName")}
+	case "http", "h2":
+		transport := &SingBoxTransport{Type: "http", Path: link.Params.Get("path")}
+		if host := link.Params.Get("host"); host != "" {
+			transport.Host = []string{host}
+		}
+		return transport
+	case "httpupgrade":
+		transport := &SingBoxTransport{Type: "httpupgrade", Path: link.Params.Get("path")}
+		if host := link.Params.Get("host"); host != "" {
+			transport.Host = []string{host}
+		}
+		return transport
+	default:
+		return &SingBoxTransport{Type: network}
+	}
+}
+
+func singBoxOutbound(tag string, link *ParsedLink) (*SingBoxOutbound, error) {
+	out := &SingBoxOutbound{
+		Tag:        tag,
+		Server:     link.Host,
+		ServerPort: link.Port,
+	}
+
+	switch link.Protocol {
+	case "vless":
+		out.Type = "vless"
+		out.UUID = link.Username
+		out.Flow = link.Params.Get("flow")
+		if security := link.Params.Get("security"); security == "tls" || security == "reality" {
+			out.TLS = singBoxTLS(link)
+		}
+		out.Transport = singBoxTransport(link)
+	case "trojan":
+		out.Type = "trojan"
+		out.Password = link.Password
+		out.TLS = singBoxTLS(link)
+		out.Transport = singBoxTransport(link)
+	case "ss":
+		out.Type = "shadowsocks"
+		out.Method = link.Method
+		out.Password = link.Password
+	case "hysteria2":
+		out.Type = "hysteria2"
+		out.Password = link.Password
+		out.TLS = singBoxTLS(link)
+		if obfs := link.Params.Get("obfs"); obfs != "" {
+			out.Obfs = &SingBoxObfs{Type: obfs, Password: link.Params.Get("obfs-password")}
+		}
+	case "tuic":
+		out.Type = "tuic"
+		out.UUID = link.Username
+		out.Password = link.Password
+		out.CongestionControl = link.Params.Get("congestion_control")
+		out.UDPRelayMode = link.Params.Get("udp_relay_mode")
+		out.TLS = singBoxTLS(link)
+		out.TLS.Insecure = out.TLS.Insecure || isTrueParam(link.Params.Get("allow_insecure"))
+	case "http":
+		out.Type = "http"
+		out.Username = link.Username
+		out.Password = link.Password
+		if link.Scheme == "https" {
+			out.TLS = &SingBoxTLS{Enabled: true, ServerName: link.Host}
+		}
+	case "socks":
+		out.Type = "socks"
+		out.Version = "5"
+		out.Username = link.Username
+		out.Password = link.Password
+	default:
+		return nil, fmt.Errorf("unsupported protocol %q", link.Protocol)
+	}
+
+	return out, nil
+}
+
+func singBoxWireGuardEndpoint(tag string, link *ParsedLink) *SingBoxEndpoint {
+	endpoint := &SingBoxEndpoint{
+		Type:       "wireguard",
+		Tag:        tag,
+		PrivateKey: link.Password,
+		Peers: []SingBoxWireGuardPeer{{
+			Address:      link.Host,
+			Port:         link.Port,
+			PublicKey:    link.Params.Get("publickey"),
+			PreSharedKey: link.Params.Get("presharedkey"),
+			AllowedIPs:   []string{"0.0.0.0/0", "::/0"},
+		}},
+	}
+	for _, a := range strings.Split(link.Params.Get("address"), ",") {
+		endpoint.Address = append(endpoint.Address, strings.TrimSpace(a))
+	}
+	if allowed := link.Params.Get("allowedips"); allowed != "" {
+		endpoint.Peers[0].AllowedIPs = nil
+		for _, a := range strings.Split(allowed, ",") {
+			endpoint.Peers[0].AllowedIPs = append(endpoint.Peers[0].AllowedIPs, strings.TrimSpace(a))
+		}
+	}
+	if mtu, err := strconv.Atoi(link.Params.Get("mtu")); err == nil {
+		endpoint.MTU = mtu
+	}
+	return endpoint
+}
+
+func BuildSingBoxConfig(servers []*ProxyServerInfo) *SingBoxConfig {
+	used := make(map[string]bool)
+	var proxies []SingBoxOutbound
+	var endpoints []SingBoxEndpoint
+	var tags []string
+
+	for _, server := range servers {
+		server.ProxyLinks.Each(func(protocol *ProxyProtocol, index int, raw string) {
+			link, err := protocol.Parse(raw)
+			if err != nil {
+				return
+			}
+			tag := uniqueProxyName(used, subscriptionRemark(server, protocol, index))
+			if link.Protocol == "wireguard" {
+				endpoints = append(endpoints, *singBoxWireGuardEndpoint(tag, link))
+				tags = append(tags, tag)
+				return
+			}
+			out, err := singBoxOutbound(tag, link)
+			if err != nil {
+				return
+			}
+			proxies = append(proxies, *out)
+			tags = append(tags, tag)
+		})
+	}
+
+	urlTestTags := tags
+	if len(urlTestTags) == 0 {
+		urlTestTags = []string{singBoxDirectTag}
+	}
+
+	outbounds := []SingBoxOutbound{
+		{
+			Type:      "selector",
+			Tag:       singBoxSelectorTag,
+			Outbounds: append([]string{singBoxURLTestTag, singBoxDirectTag}, tags...),
+			Default:   singBoxURLTestTag,
+		},
+		{
+			Type:      "urltest",
+			Tag:       singBoxURLTestTag,
+			Outbounds: urlTestTags,
+			URL:       proxyTestURL,
+			Interval:  "5m",
+			Tolerance: 50,
+		},
+	}
+	outbounds = append(outbounds, proxies...)
+	outbounds = append(outbounds, SingBoxOutbound{Type: "direct", Tag: singBoxDirectTag})
+
+	return &SingBoxConfig{
+		Log: SingBoxLog{Level: "info"},
+		Inbounds: []SingBoxInbound{
+			{Type: "mixed", Tag: "mixed-in", Listen: "127.0.0.1", ListenPort: 2080},
+		},
+		Outbounds: outbounds,
+		Endpoints: endpoints,
+		Route: SingBoxRoute{
+			Rules: []SingBoxRouteRule{
+				{IPIsPrivate: true, Outbound: singBoxDirectTag},
+			},
+			Final:               singBoxSelectorTag,
+			AutoDetectInterface: true,
+		},
+	}
+}
+
+func singBoxConfigHandle(w http.ResponseWriter, r *http.Request) {
+	data, err := json.MarshalIndent(BuildSingBoxConfig(serverRegistry.Servers()), "", "  ")
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	setSubscriptionHeaders(r.Context(), w.Header())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="proxyhub-singbox.json"`)
+
+	w.Write(data)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildSingBoxConfigGolden(t *testing.T) {
+	for _, tc := range goldenProtocols {
+		t.Run(tc.name, func(t *testing.T) {
+			config := BuildSingBoxConfig(goldenServer(tc.links))
+			if len(config.Outbounds)+len(config.Endpoints) < 4 {
+				t.Fatalf("no proxy generated: %+v", config)
+			}
+			data, err := json.MarshalIndent(config, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, filepath.Join("testdata", "singbox", tc.name+".json"), append(data, '\n'))
+		})
+	}
+}
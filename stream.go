@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	streamClientBuffer = 32
+	streamHeartbeat    = 25 * time.Second
+)
+
+var eventStream *EventStream
+
+type StreamEvent struct {
+	Name string
+	Data []byte
+}
+
+type EventStream struct {
+	mu      sync.Mutex
+	clients map[chan StreamEvent]struct{}
+	closed  bool
+}
+
+func NewEventStream() *EventStream {
+	return &EventStream{clients: make(map[chan StreamEvent]struct{})}
+}
+
+func (s *EventStream) Subscribe() (chan StreamEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, false
+	}
+	ch := make(chan StreamEvent, streamClientBuffer)
+	s.clients[ch] = struct{}{}
+	return ch, true
+}
+
+func (s *EventStream) Unsubscribe(ch chan StreamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[ch]; ok {
+		delete(s.clients, ch)
+		close(ch)
+	}
+}
+
+func (s *EventStream) Clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+func (s *EventStream) Publish(name string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Stream event %s marshal error: %v", name, err)
+		return
+	}
+	event := StreamEvent{Name: name, Data: data}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.clients {
+		select {
+		case ch <- event:
+		default:
+			// Slow client: drop it, EventSource reconnects and gets a fresh snapshot.
+			delete(s.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *EventStream) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastOverview *Overview
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.closed = true
+			for ch := range s.clients {
+				delete(s.clients, ch)
+				close(ch)
+			}
+			s.mu.Unlock()
+			return
+		case <-ticker.C:
+			if s.Clients() == 0 {
+				continue
+			}
+			if overview, err := fleetOverview.Get(ctx); err == nil && overview != lastOverview {
+				lastOverview = overview
+				s.Publish("overview", overview)
+			}
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, event StreamEvent) error {
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Name, event.Data)
+	return err
+}
+
+func streamHandle(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch, ok := eventStream.Subscribe()
+	if !ok {
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer eventStream.Unsubscribe(ch)
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, health := range healthChecker.All() {
+		data, _ := json.Marshal(health)
+		if writeStreamEvent(w, StreamEvent{Name: "health", Data: data}) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	overview, err := fleetOverview.Get(r.Context())
+	if err != nil {
+		return
+	}
+	data, _ := json.Marshal(overview)
+	if writeStreamEvent(w, StreamEvent{Name: "overview", Data: data}) != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			if writeStreamEvent(w, event) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
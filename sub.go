@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const subscriptionUpdateInterval = 12
+
+type SubscriptionUsage struct {
+	Upload   uint64
+	Download uint64
+	Total    uint64
+}
+
+func subscriptionRemark(server *ProxyServerInfo, protocol *ProxyProtocol, index int) string {
+	remark := server.Name
+	if server.Location != "" {
+		remark += " (" + server.Location + ")"
+	}
+	remark += " " + protocol.Key
+	if len(protocol.Links(&server.ProxyLinks)) > 1 {
+		remark += " " + strconv.Itoa(index+1)
+	}
+	return remark
+}
+
+func withRemark(raw, remark string) string {
+	raw, _, _ = strings.Cut(strings.TrimSpace(raw), "#")
+	return raw + "#" + (&url.URL{Fragment: remark}).EscapedFragment()
+}
+
+func buildSubscription(servers []*ProxyServerInfo) []string {
+	var lines []string
+	for _, server := range servers {
+		server.ProxyLinks.Each(func(protocol *ProxyProtocol, index int, raw string) {
+			if strings.ContainsAny(strings.TrimSpace(raw), "\r\n") {
+				return
+			}
+			if _, err := protocol.Parse(raw); err != nil {
+				return
+			}
+			lines = append(lines, withRemark(raw, subscriptionRemark(server, protocol, index)))
+		})
+	}
+	return lines
+}
+
+// subscriptionUsage sums the current quota cycles of every server with a
+// structured limit. Server rx is what clients upload, server tx what they download.
+func subscriptionUsage(overview *Overview) (SubscriptionUsage, bool) {
+	var usage SubscriptionUsage
+	found := false
+	for _, server := range overview.Servers {
+		if server.Quota == nil {
+			continue
+		}
+		found = true
+		usage.Upload += server.Quota.UsedRxBytes
+		usage.Download += server.Quota.UsedTxBytes
+		usage.Total += server.Quota.LimitBytes
+	}
+	return usage, found
+}
+
+func setSubscriptionHeaders(ctx context.Context, header http.Header) {
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Profile-Title", "base64:"+base64.StdEncoding.EncodeToString([]byte("ProxyHub")))
+	header.Set("Profile-Update-Interval", strconv.Itoa(subscriptionUpdateInterval))
+
+	if fleetOverview == nil {
+		return
+	}
+	overview, err := fleetOverview.Get(ctx)
+	if err != nil {
+		return
+	}
+	if usage, ok := subscriptionUsage(overview); ok {
+		header.Set("Subscription-Userinfo", fmt.Sprintf("upload=%d; download=%d; total=%d",
+			usage.Upload, usage.Download, usage.Total))
+	}
+}
+
+func subscriptionHandle(w http.ResponseWriter, r *http.Request) {
+	lines := buildSubscription(serverRegistry.Servers())
+
+	setSubscriptionHeaders(r.Context(), w.Header())
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	fmt.Fprint(w, base64.StdEncoding.EncodeToString([]byte(strings.Join(lines, "\n"))))
+}
@@ -0,0 +1,502 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const clockTicks = 100
+
+var cpuModes = []string{"user", "nice", "system", "idle", "iowait", "irq", "softirq", "steal"}
+
+var systemCollector = NewSystemCollector("/")
+
+type SystemCollector struct {
+	Root string
+}
+
+type OSInfo struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	PrettyName string `json:"prettyName"`
+	Version    string `json:"version"`
+}
+
+type CPUInfo struct {
+	Model   string  `json:"model"`
+	Cores   int     `json:"cores"`
+	MHz     float64 `json:"mhz"`
+	Sockets int     `json:"sockets"`
+}
+
+type MemoryInfo struct {
+	TotalBytes     uint64 `json:"totalBytes"`
+	AvailableBytes uint64 `json:"availableBytes"`
+	UsedBytes      uint64 `json:"usedBytes"`
+	SwapTotalBytes uint64 `json:"swapTotalBytes"`
+	SwapFreeBytes  uint64 `json:"swapFreeBytes"`
+}
+
+type DiskInfo struct {
+	Mountpoint     string `json:"mountpoint"`
+	Device         string `json:"device,omitempty"`
+	FSType         string `json:"fsType,omitempty"`
+	TotalBytes     uint64 `json:"totalBytes"`
+	AvailableBytes uint64 `json:"availableBytes"`
+	UsedBytes      uint64 `json:"usedBytes"`
+}
+
+type LocalIPInfo struct {
+	Interface string `json:"interface"`
+	Address   string `json:"address"`
+}
+
+type NetCounters struct {
+	Name    string `json:"name"`
+	RxBytes uint64 `json:"rxBytes"`
+	TxBytes uint64 `json:"txBytes"`
+}
+
+type SystemInfo struct {
+	Hostname      string       `json:"hostname"`
+	Kernel        string       `json:"kernel"`
+	OS            OSInfo       `json:"os"`
+	CPU           CPUInfo      `json:"cpu"`
+	Memory        MemoryInfo   `json:"memory"`
+	Disk          *DiskInfo    `json:"disk,omitempty"`
+	LocalIP       *LocalIPInfo `json:"localIp,omitempty"`
+	Load          [3]float64   `json:"load"`
+	UptimeSeconds float64      `json:"uptimeSeconds"`
+}
+
+func NewSystemCollector(root string) *SystemCollector {
+	return &SystemCollector{Root: root}
+}
+
+func (c *SystemCollector) path(elem ...string) string {
+	return filepath.Join(append([]string{c.Root}, elem...)...)
+}
+
+func (c *SystemCollector) readString(elem ...string) (string, error) {
+	content, err := os.ReadFile(c.path(elem...))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func (c *SystemCollector) readUint(elem ...string) (uint64, error) {
+	s, err := c.readString(elem...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+func (c *SystemCollector) readFloats(n int, elem ...string) ([]float64, error) {
+	s, err := c.readString(elem...)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(s)
+	if len(fields) < n {
+		return nil, fmt.Errorf("%s: expected %d fields, got %d", filepath.Join(elem...), n, len(fields))
+	}
+	result := make([]float64, n)
+	for i := range n {
+		if result[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (c *SystemCollector) OSRelease() (OSInfo, error) {
+	var info OSInfo
+	file, err := os.Open(c.path("etc", "os-release"))
+	if err != nil {
+		file, err = os.Open(c.path("usr", "lib", "os-release"))
+	}
+	if err != nil {
+		return info, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `'"`)
+		}
+		switch key {
+		case "ID":
+			info.ID = value
+		case "NAME":
+			info.Name = value
+		case "PRETTY_NAME":
+			info.PrettyName = value
+		case "VERSION_ID":
+			info.Version = value
+		}
+	}
+	if info.PrettyName == "" {
+		info.PrettyName = strings.TrimSpace(info.Name + " " + info.Version)
+	}
+	return info, scanner.Err()
+}
+
+func (c *SystemCollector) CPUInfo() (CPUInfo, error) {
+	var info CPUInfo
+	file, err := os.Open(c.path("proc", "cpuinfo"))
+	if err != nil {
+		return info, err
+	}
+	defer file.Close()
+
+	sockets := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "processor":
+			info.Cores++
+		case "model name", "Model", "cpu model":
+			if info.Model == "" {
+				info.Model = value
+			}
+		case "cpu MHz":
+			if info.MHz == 0 {
+				info.MHz, _ = strconv.ParseFloat(value, 64)
+			}
+		case "physical id":
+			sockets[value] = true
+		}
+	}
+	info.Sockets = max(len(sockets), 1)
+	return info, scanner.Err()
+}
+
+func (c *SystemCollector) MemInfo() (map[string]uint64, error) {
+	file, err := os.Open(c.path("proc", "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			v *= 1024
+		}
+		result[key] = v
+	}
+	return result, scanner.Err()
+}
+
+func (c *SystemCollector) Memory() (MemoryInfo, error) {
+	mem, err := c.MemInfo()
+	if err != nil {
+		return MemoryInfo{}, err
+	}
+	available, ok := mem["MemAvailable"]
+	if !ok {
+		available = mem["MemFree"] + mem["Buffers"] + mem["Cached"]
+	}
+	return MemoryInfo{
+		TotalBytes:     mem["MemTotal"],
+		AvailableBytes: available,
+		UsedBytes:      mem["MemTotal"] - min(available, mem["MemTotal"]),
+		SwapTotalBytes: mem["SwapTotal"],
+		SwapFreeBytes:  mem["SwapFree"],
+	}, nil
+}
+
+func (c *SystemCollector) Disk(mountpoint string) (DiskInfo, error) {
+	total, avail, err := diskUsage(c.path(mountpoint))
+	if err != nil {
+		return DiskInfo{}, err
+	}
+	return DiskInfo{
+		Mountpoint:     mountpoint,
+		TotalBytes:     total,
+		AvailableBytes: avail,
+		UsedBytes:      total - min(avail, total),
+	}, nil
+}
+
+func (c *SystemCollector) Disks() ([]DiskInfo, error) {
+	s, err := c.readString("proc", "mounts")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []DiskInfo
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "/dev/") || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+
+		disk, err := c.Disk(fields[1])
+		if err != nil {
+			continue
+		}
+		disk.Device = fields[0]
+		disk.FSType = fields[2]
+		result = append(result, disk)
+	}
+	return result, nil
+}
+
+func (c *SystemCollector) Uptime() (float64, error) {
+	v, err := c.readFloats(1, "proc", "uptime")
+	if err != nil {
+		return 0, err
+	}
+	return v[0], nil
+}
+
+func (c *SystemCollector) LoadAvg() ([3]float64, error) {
+	v, err := c.readFloats(3, "proc", "loadavg")
+	if err != nil {
+		return [3]float64{}, err
+	}
+	return [3]float64{v[0], v[1], v[2]}, nil
+}
+
+func (c *SystemCollector) CPUSeconds() (map[string]float64, error) {
+	s, err := c.readString("proc", "stat")
+	if err != nil {
+		return nil, err
+	}
+	line, _, _ := strings.Cut(s, "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "cpu" {
+		return nil, fmt.Errorf("proc/stat: missing cpu line")
+	}
+	result := make(map[string]float64)
+	for i, mode := range cpuModes {
+		if i+1 >= len(fields) {
+			break
+		}
+		v, err := strconv.ParseUint(fields[i+1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		result[mode] = float64(v) / clockTicks
+	}
+	return result, nil
+}
+
+func (c *SystemCollector) BootID() string {
+	id, _ := c.readString("proc", "sys", "kernel", "random", "boot_id")
+	return id
+}
+
+func (c *SystemCollector) DefaultInterface() string {
+	s, err := c.readString("proc", "net", "route")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(s, "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) > 1 && fields[1] == "00000000" {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+// parseRouteHex decodes an address or mask from /proc/net/route, which the
+// kernel prints in host byte order.
+func parseRouteHex(s string) (netip.Addr, bool) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 4 {
+		return netip.Addr{}, false
+	}
+	var addr [4]byte
+	binary.BigEndian.PutUint32(addr[:], binary.LittleEndian.Uint32(b))
+	return netip.AddrFrom4(addr), true
+}
+
+// LocalIP returns the IPv4 address of the default route interface, found by
+// matching the local addresses in /proc/net/fib_trie against its subnets.
+func (c *SystemCollector) LocalIP() (LocalIPInfo, bool) {
+	iface := c.DefaultInterface()
+	routes, err := c.readString("proc", "net", "route")
+	if iface == "" || err != nil {
+		return LocalIPInfo{}, false
+	}
+	var subnets []netip.Prefix
+	for _, line := range strings.Split(routes, "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 8 || fields[0] != iface || fields[1] == "00000000" {
+			continue
+		}
+		dst, ok1 := parseRouteHex(fields[1])
+		mask, ok2 := parseRouteHex(fields[7])
+		if !ok1 || !ok2 {
+			continue
+		}
+		m := mask.As4()
+		subnets = append(subnets, netip.PrefixFrom(dst, bits.OnesCount32(binary.BigEndian.Uint32(m[:]))))
+	}
+
+	trie, err := c.readString("proc", "net", "fib_trie")
+	if err != nil {
+		return LocalIPInfo{}, false
+	}
+	lines := strings.Split(trie, "\n")
+	for i := 1; i < len(lines); i++ {
+		if !strings.HasSuffix(lines[i], "/32 host LOCAL") {
+			continue
+		}
+		addr, err := netip.ParseAddr(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i-1]), "|--")))
+		if err != nil {
+			continue
+		}
+		for _, subnet := range subnets {
+			if subnet.Contains(addr) {
+				return LocalIPInfo{Interface: iface, Address: netip.PrefixFrom(addr, subnet.Bits()).String()}, true
+			}
+		}
+	}
+	return LocalIPInfo{}, false
+}
+
+func (c *SystemCollector) NetCounters() []NetCounters {
+	dirs, _ := filepath.Glob(c.path("sys", "class", "net", "*", "statistics"))
+	var result []NetCounters
+	for _, dir := range dirs {
+		name := filepath.Base(filepath.Dir(dir))
+		if name == "lo" {
+			continue
+		}
+		rx, err := c.readUint("sys", "class", "net", name, "statistics", "rx_bytes")
+		if err != nil {
+			continue
+		}
+		tx, err := c.readUint("sys", "class", "net", name, "statistics", "tx_bytes")
+		if err != nil {
+			continue
+		}
+		result = append(result, NetCounters{Name: name, RxBytes: rx, TxBytes: tx})
+	}
+	return result
+}
+
+func (c *SystemCollector) Collect() *SystemInfo {
+	info := new(SystemInfo)
+	info.Hostname, _ = c.readString("proc", "sys", "kernel", "hostname")
+	info.Kernel, _ = c.readString("proc", "sys", "kernel", "osrelease")
+	info.OS, _ = c.OSRelease()
+	info.CPU, _ = c.CPUInfo()
+	info.Memory, _ = c.Memory()
+	if disk, err := c.Disk("/"); err == nil {
+		info.Disk = &disk
+	}
+	if localIP, ok := c.LocalIP(); ok {
+		info.LocalIP = &localIP
+	}
+	info.Load, _ = c.LoadAvg()
+	info.UptimeSeconds, _ = c.Uptime()
+	return info
+}
+
+func formatBytesIEC(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func formatUptime(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	mins := int(d.Minutes()) % 60
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%d days", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%d hours", hours))
+	}
+	parts = append(parts, fmt.Sprintf("%d mins", mins))
+	return strings.Join(parts, ", ")
+}
+
+func usagePercent(used, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(used) / float64(total) * 100
+}
+
+func (info *SystemInfo) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "OS: %s\n", info.OS.PrettyName)
+	fmt.Fprintf(&b, "Host: %s\n", info.Hostname)
+	fmt.Fprintf(&b, "Kernel: %s\n", info.Kernel)
+	fmt.Fprintf(&b, "Uptime: %s\n", formatUptime(info.UptimeSeconds))
+	fmt.Fprintf(&b, "CPU: %s (%d)\n", info.CPU.Model, info.CPU.Cores)
+	fmt.Fprintf(&b, "Load: %.2f %.2f %.2f\n", info.Load[0], info.Load[1], info.Load[2])
+	fmt.Fprintf(&b, "Memory: %s / %s (%.0f%%)\n",
+		formatBytesIEC(info.Memory.UsedBytes), formatBytesIEC(info.Memory.TotalBytes),
+		usagePercent(info.Memory.UsedBytes, info.Memory.TotalBytes))
+	if info.Memory.SwapTotalBytes > 0 {
+		swapUsed := info.Memory.SwapTotalBytes - min(info.Memory.SwapFreeBytes, info.Memory.SwapTotalBytes)
+		fmt.Fprintf(&b, "Swap: %s / %s (%.0f%%)\n",
+			formatBytesIEC(swapUsed), formatBytesIEC(info.Memory.SwapTotalBytes),
+			usagePercent(swapUsed, info.Memory.SwapTotalBytes))
+	}
+	if info.Disk != nil {
+		fmt.Fprintf(&b, "Disk (%s): %s / %s (%.0f%%)\n", info.Disk.Mountpoint,
+			formatBytesIEC(info.Disk.UsedBytes), formatBytesIEC(info.Disk.TotalBytes),
+			usagePercent(info.Disk.UsedBytes, info.Disk.TotalBytes))
+	}
+	if info.LocalIP != nil {
+		fmt.Fprintf(&b, "Local IP (%s): %s\n", info.LocalIP.Interface, info.LocalIP.Address)
+	}
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var fixtureCollector = NewSystemCollector("testdata")
+
+func TestSystemCollectorOSRelease(t *testing.T) {
+	info, err := fixtureCollector.OSRelease()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := OSInfo{ID: "debian", Name: "Debian GNU/Linux", PrettyName: "Debian GNU/Linux 12 (bookworm)", Version: "12"}
+	if info != want {
+		t.Errorf("OSRelease() = %+v, want %+v", info, want)
+	}
+}
+
+func TestSystemCollectorCPUInfo(t *testing.T) {
+	info, err := fixtureCollector.CPUInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CPUInfo{Model: "AMD EPYC 7543 32-Core Processor", Cores: 2, MHz: 2794.748, Sockets: 1}
+	if info != want {
+		t.Errorf("CPUInfo() = %+v, want %+v", info, want)
+	}
+}
+
+func TestSystemCollectorMemory(t *testing.T) {
+	info, err := fixtureCollector.Memory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MemoryInfo{
+		TotalBytes:     2014548 * 1024,
+		AvailableBytes: 1249836 * 1024,
+		UsedBytes:      (2014548 - 1249836) * 1024,
+		SwapTotalBytes: 1048572 * 1024,
+		SwapFreeBytes:  917500 * 1024,
+	}
+	if info != want {
+		t.Errorf("Memory() = %+v, want %+v", info, want)
+	}
+
+	mem, err := fixtureCollector.MemInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := mem["HugePages_Total"]; !ok || v != 0 {
+		t.Errorf("MemInfo()[HugePages_Total] = %d, %v; want unitless 0", v, ok)
+	}
+}
+
+func TestSystemCollectorUptime(t *testing.T) {
+	uptime, err := fixtureCollector.Uptime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uptime != 266543.12 {
+		t.Errorf("Uptime() = %v, want 266543.12", uptime)
+	}
+	if got := formatUptime(uptime); got != "3 days, 2 hours, 2 mins" {
+		t.Errorf("formatUptime(%v) = %q", uptime, got)
+	}
+}
+
+func TestSystemCollectorLoadAvg(t *testing.T) {
+	load, err := fixtureCollector.LoadAvg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [3]float64{0.10, 0.25, 0.30}; load != want {
+		t.Errorf("LoadAvg() = %v, want %v", load, want)
+	}
+}
+
+func TestSystemCollectorNetwork(t *testing.T) {
+	if got := fixtureCollector.DefaultInterface(); got != "eth0" {
+		t.Errorf("DefaultInterface() = %q, want eth0", got)
+	}
+
+	counters := fixtureCollector.NetCounters()
+	want := []NetCounters{{Name: "eth0", RxBytes: 123456789, TxBytes: 98765432}}
+	if len(counters) != 1 || counters[0] != want[0] {
+		t.Errorf("NetCounters() = %+v, want %+v", counters, want)
+	}
+}
+
+func TestSystemCollectorCPUSeconds(t *testing.T) {
+	seconds, err := fixtureCollector.CPUSeconds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seconds["user"] != 100 || seconds["idle"] != 9000 || seconds["steal"] != 0.5 {
+		t.Errorf("CPUSeconds() = %v", seconds)
+	}
+}
+
+func TestSystemCollectorLocalIP(t *testing.T) {
+	info, ok := fixtureCollector.LocalIP()
+	want := LocalIPInfo{Interface: "eth0", Address: "192.168.113.5/24"}
+	if !ok || info != want {
+		t.Errorf("LocalIP() = %+v, %v; want %+v", info, ok, want)
+	}
+
+	if s := fixtureCollector.Collect().String(); !strings.Contains(s, "Local IP (eth0): 192.168.113.5/24\n") {
+		t.Errorf("String() has no local IP line:\n%s", s)
+	}
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"html"
 	"log"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -16,6 +21,7 @@ import (
 
 var telebotOwner int64
 var telebotAccessCode string
+var telebotInstance atomic.Pointer[bot.Bot]
 
 type TelebotParams struct {
 	Token         string
@@ -51,6 +57,14 @@ func RunTelebot(ctx context.Context, stop context.CancelFunc, params *TelebotPar
 				Command:     "client",
 				Description: "👤 Клиент",
 			},
+			{
+				Command:     "qr",
+				Description: "📱 QR код подписки",
+			},
+			{
+				Command:     "status",
+				Description: "📡 Состояние серверов",
+			},
 		},
 	})
 
@@ -103,6 +117,8 @@ func RunTelebot(ctx context.Context, stop context.CancelFunc, params *TelebotPar
 		log.Fatalf("ReadTelebotUsersFromFile error: %v", err)
 	}
 
+	telebotInstance.Store(b)
+
 	log.Println("Telegram bot started.")
 	b.Start(ctx)
 	log.Println("Telegram bot stopped gracefully.")
@@ -245,6 +261,30 @@ func TrimCommand(text string, command string) string {
 	return strings.TrimSpace(strings.TrimPrefix(text, command))
 }
 
+func NotifyOwner(ctx context.Context, text string) error {
+	b := telebotInstance.Load()
+	if b == nil {
+		return errors.New("telegram bot is not running")
+	}
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    telebotOwner,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	return err
+}
+
+func ForwardToAllUsers(ctx context.Context, b *bot.Bot, messageID int) {
+	for _, userID := range GetAllUserIDs() {
+		_, err := b.ForwardMessage(ctx, &bot.ForwardMessageParams{
+			ChatID:     userID,
+			FromChatID: telebotOwner,
+			MessageID:  messageID,
+		})
+		hubMetrics.BroadcastResult(err)
+	}
+}
+
 func GetClientForUser(ctx context.Context, b *bot.Bot, userID int64) (*models.Message, error) {
 	clientText := fmt.Sprintf(`<u><i><b>👤 Client</b></i></u>
 
@@ -268,6 +308,12 @@ func GetClientForUser(ctx context.Context, b *bot.Bot, userID int64) (*models.Me
 						URL:  serverFullExternalURL + "#Servers",
 					},
 				},
+				{
+					{
+						Text:         "📱 QR подписки",
+						CallbackData: "sub_qr",
+					},
+				},
 				{
 					{
 						Text: "🍩 Donut",
@@ -286,6 +332,62 @@ func GetClientForUser(ctx context.Context, b *bot.Bot, userID int64) (*models.Me
 	})
 }
 
+func SendQRCode(ctx context.Context, b *bot.Bot, chatID int64, content string, caption string) (*models.Message, error) {
+	qr, err := EncodeQRCode([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	data, err := qr.PNG(8)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID: chatID,
+		Photo: &models.InputFileUpload{
+			Filename: "qr.png",
+			Data:     bytes.NewReader(data),
+		},
+		Caption:   caption,
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+func SendQRCodeForCommand(ctx context.Context, b *bot.Bot, chatID int64, args string) (*models.Message, error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return SendQRCode(ctx, b, chatID, subscriptionURL(), "📱 Подписка ProxyHub")
+	}
+
+	index := ""
+	if len(fields) > 1 {
+		index = fields[1]
+	}
+	content, err := qrContent(fields[0], "", index)
+	if err != nil {
+		return nil, err
+	}
+
+	return SendQRCode(ctx, b, chatID, content, fmt.Sprintf("📱 <b>%s</b> #%s", html.EscapeString(fields[0]), html.EscapeString(cmp.Or(index, "0"))))
+}
+
+func GetServersStatusText() string {
+	var sb strings.Builder
+	sb.WriteString("<u><i><b>📡 Servers</b></i></u>\n\n")
+	for _, health := range healthChecker.All() {
+		name := html.EscapeString(health.Name)
+		switch health.Status {
+		case HealthUp:
+			fmt.Fprintf(&sb, "🟢 <b>%s</b> — %d ms\n", name, health.LatencyMs)
+		case HealthDown:
+			fmt.Fprintf(&sb, "🔴 <b>%s</b> — %s\n", name, html.EscapeString(health.LastError))
+		default:
+			fmt.Fprintf(&sb, "⚪ <b>%s</b>\n", name)
+		}
+	}
+	return sb.String()
+}
+
 func handler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if update.Message == nil {
 		return
@@ -324,23 +426,11 @@ func handler(ctx context.Context, b *bot.Bot, update *models.Update) {
 			return
 		}
 		if strings.HasPrefix(update.Message.Text, "/send") {
-			for _, userID := range GetAllUserIDs() {
-				b.ForwardMessage(ctx, &bot.ForwardMessageParams{
-					ChatID:     userID,
-					FromChatID: telebotOwner,
-					MessageID:  update.Message.ID,
-				})
-			}
+			ForwardToAllUsers(ctx, b, update.Message.ID)
 			return
 		}
 		if strings.HasPrefix(update.Message.Caption, "/send") {
-			for _, userID := range GetAllUserIDs() {
-				b.ForwardMessage(ctx, &bot.ForwardMessageParams{
-					ChatID:     userID,
-					FromChatID: telebotOwner,
-					MessageID:  update.Message.ID,
-				})
-			}
+			ForwardToAllUsers(ctx, b, update.Message.ID)
 			return
 		}
 	}
@@ -355,6 +445,14 @@ func handler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if strings.HasPrefix(update.Message.Text, "/client") {
 		GetClientForUser(ctx, b, update.Message.Chat.ID)
 	}
+	if strings.HasPrefix(update.Message.Text, "/status") {
+		replay(GetServersStatusText())
+	}
+	if strings.HasPrefix(update.Message.Text, "/qr") {
+		if _, err := SendQRCodeForCommand(ctx, b, update.Message.Chat.ID, TrimCommand(update.Message.Text, "/qr")); err != nil {
+			replay("Ошибка: " + html.EscapeString(err.Error()))
+		}
+	}
 
 	// strUpd, _ := json.MarshalIndent(update, "", "     ")
 	// fmt.Printf("%s\n", string(strUpd))
@@ -395,6 +493,9 @@ func сallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 			},
 		})
 	}
+	if update.CallbackQuery.Data == "sub_qr" {
+		SendQRCode(ctx, b, update.CallbackQuery.From.ID, subscriptionURL(), "📱 Подписка ProxyHub")
+	}
 	if update.CallbackQuery.Data == "del_auth_" {
 		b.DeleteMessage(ctx, &bot.DeleteMessageParams{
 			ChatID:    update.CallbackQuery.From.ID,
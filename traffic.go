@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	trafficSampleInterval = 30 * time.Second
+	trafficSaveInterval   = 5 * time.Minute
+	trafficStaleInterface = 62 * 24 * time.Hour
+)
+
+var trafficSampler *TrafficSampler
+
+type trafficGranularity struct {
+	Mode      string
+	Retention int
+	Start     func(t time.Time) time.Time
+}
+
+var trafficGranularities = []trafficGranularity{
+	{"f", 576, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()-t.Minute()%5, 0, 0, t.Location())
+	}},
+	{"h", 168, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	}},
+	{"d", 62, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}},
+	{"m", 25, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}},
+}
+
+func isTrafficMode(mode string) bool {
+	return slices.ContainsFunc(trafficGranularities, func(g trafficGranularity) bool {
+		return g.Mode == mode
+	})
+}
+
+type TrafficBucket struct {
+	Timestamp int64  `json:"timestamp"`
+	Rx        uint64 `json:"rx"`
+	Tx        uint64 `json:"tx"`
+}
+
+type TrafficInterface struct {
+	Created int64                      `json:"created"`
+	Updated int64                      `json:"updated"`
+	LastRx  uint64                     `json:"lastRx"`
+	LastTx  uint64                     `json:"lastTx"`
+	Total   TrafficStats               `json:"total"`
+	Buckets map[string][]TrafficBucket `json:"buckets"`
+}
+
+type trafficState struct {
+	BootID     string                       `json:"bootId"`
+	Interfaces map[string]*TrafficInterface `json:"interfaces"`
+}
+
+type TrafficSampler struct {
+	collector *SystemCollector
+	path      string
+	mu        sync.Mutex
+	state     trafficState
+}
+
+func NewTrafficSampler(collector *SystemCollector, path string) (*TrafficSampler, error) {
+	s := &TrafficSampler{
+		collector: collector,
+		path:      path,
+		state:     trafficState{Interfaces: make(map[string]*TrafficInterface)},
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &s.state); err != nil {
+		return nil, err
+	}
+	if s.state.Interfaces == nil {
+		s.state.Interfaces = make(map[string]*TrafficInterface)
+	}
+
+	return s, nil
+}
+
+func (s *TrafficSampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(trafficSampleInterval)
+	defer ticker.Stop()
+
+	lastSave := time.Now()
+	for {
+		s.Sample(time.Now())
+		if time.Since(lastSave) >= trafficSaveInterval {
+			if err := s.Save(); err != nil {
+				log.Printf("Traffic history save error: %v", err)
+			}
+			lastSave = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := s.Save(); err != nil {
+				log.Printf("Traffic history save error: %v", err)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func counterDelta(prev, cur uint64, rebooted bool) uint64 {
+	if rebooted || cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+func (s *TrafficSampler) Sample(now time.Time) {
+	counters := s.collector.NetCounters()
+	bootID := s.collector.BootID()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rebooted := bootID != "" && s.state.BootID != "" && bootID != s.state.BootID
+	s.state.BootID = bootID
+
+	for _, c := range counters {
+		iface, ok := s.state.Interfaces[c.Name]
+		if !ok {
+			s.state.Interfaces[c.Name] = &TrafficInterface{
+				Created: now.Unix(),
+				Updated: now.Unix(),
+				LastRx:  c.RxBytes,
+				LastTx:  c.TxBytes,
+				Buckets: make(map[string][]TrafficBucket),
+			}
+			continue
+		}
+
+		rx := counterDelta(iface.LastRx, c.RxBytes, rebooted)
+		tx := counterDelta(iface.LastTx, c.TxBytes, rebooted)
+		iface.LastRx, iface.LastTx = c.RxBytes, c.TxBytes
+		iface.Updated = now.Unix()
+		iface.add(now, rx, tx)
+	}
+
+	cutoff := now.Add(-trafficStaleInterface).Unix()
+	for name, iface := range s.state.Interfaces {
+		if iface.Updated < cutoff {
+			delete(s.state.Interfaces, name)
+		}
+	}
+}
+
+func (iface *TrafficInterface) add(now time.Time, rx, tx uint64) {
+	iface.Total.Rx += rx
+	iface.Total.Tx += tx
+	if iface.Buckets == nil {
+		iface.Buckets = make(map[string][]TrafficBucket)
+	}
+
+	for _, g := range trafficGranularities {
+		start := g.Start(now).Unix()
+		buckets := iface.Buckets[g.Mode]
+		if n := len(buckets); n == 0 || buckets[n-1].Timestamp != start {
+			buckets = append(buckets, TrafficBucket{Timestamp: start})
+		}
+		buckets[len(buckets)-1].Rx += rx
+		buckets[len(buckets)-1].Tx += tx
+		if len(buckets) > g.Retention {
+			buckets = slices.Clone(buckets[len(buckets)-g.Retention:])
+		}
+		iface.Buckets[g.Mode] = buckets
+	}
+}
+
+func (s *TrafficSampler) Save() error {
+	s.mu.Lock()
+	content, err := json.Marshal(&s.state)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, content, 0644)
+}
+
+func vnStatTimeData(ts int64, withTime bool) VnStatTimeData {
+	t := time.Unix(ts, 0)
+	data := VnStatTimeData{
+		Date:      VnStatDate{Year: t.Year(), Month: int(t.Month()), Day: t.Day()},
+		Timestamp: ts,
+	}
+	if withTime {
+		data.Time = &VnStatTime{Hour: t.Hour(), Minute: t.Minute()}
+	}
+	return data
+}
+
+func trafficEntries(buckets []TrafficBucket, mode string, limit int) []DayStats {
+	if limit > 0 && len(buckets) > limit {
+		buckets = buckets[len(buckets)-limit:]
+	}
+
+	result := make([]DayStats, 0, len(buckets))
+	for i, b := range buckets {
+		t := time.Unix(b.Timestamp, 0)
+		entry := DayStats{
+			ID:        i,
+			Date:      VnStatDate{Year: t.Year(), Month: int(t.Month()), Day: t.Day()},
+			Timestamp: b.Timestamp,
+			Rx:        b.Rx,
+			Tx:        b.Tx,
+		}
+		switch mode {
+		case "f", "h":
+			entry.Time = &VnStatTime{Hour: t.Hour(), Minute: t.Minute()}
+		case "m":
+			entry.Date.Day = 0
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+func (s *TrafficSampler) VnStatData(mode string, limit int) *VnStatData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.state.Interfaces))
+	for name := range s.state.Interfaces {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	if def := s.collector.DefaultInterface(); def != "" {
+		if i := slices.Index(names, def); i > 0 {
+			names = slices.Insert(slices.Delete(names, i, i+1), 0, def)
+		}
+	}
+
+	data := &VnStatData{
+		VnStatVersion: "proxyhub",
+		JsonVersion:   "2",
+		Interfaces:    make([]VnStatInterface, 0, len(names)),
+	}
+	for _, name := range names {
+		iface := s.state.Interfaces[name]
+		traffic := VnStatTraffic{Total: iface.Total}
+		entries := trafficEntries(iface.Buckets[mode], mode, limit)
+		switch mode {
+		case "f":
+			traffic.FiveMinute = entries
+		case "h":
+			traffic.Hour = entries
+		case "d":
+			traffic.Day = entries
+		case "m":
+			traffic.Month = entries
+		}
+		data.Interfaces = append(data.Interfaces, VnStatInterface{
+			Name:    name,
+			Created: vnStatTimeData(iface.Created, false),
+			Updated: vnStatTimeData(iface.Updated, true),
+			Traffic: traffic,
+		})
+	}
+
+	return data
+}
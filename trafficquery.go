@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTrafficLimit = 30
+	maxTrafficLimit     = 90
+)
+
+type TrafficMode string
+
+const (
+	TrafficFiveMinute TrafficMode = "f"
+	TrafficHour       TrafficMode = "h"
+	TrafficDay        TrafficMode = "d"
+	TrafficMonth      TrafficMode = "m"
+	TrafficYear       TrafficMode = "y"
+	TrafficTop        TrafficMode = "t"
+)
+
+var trafficModeNames = map[string]TrafficMode{
+	"f":          TrafficFiveMinute,
+	"5":          TrafficFiveMinute,
+	"fiveminute": TrafficFiveMinute,
+	"h":          TrafficHour,
+	"hour":       TrafficHour,
+	"d":          TrafficDay,
+	"day":        TrafficDay,
+	"m":          TrafficMonth,
+	"month":      TrafficMonth,
+	"y":          TrafficYear,
+	"year":       TrafficYear,
+	"t":          TrafficTop,
+	"top":        TrafficTop,
+}
+
+func ParseTrafficMode(s string) (TrafficMode, error) {
+	if s == "" {
+		return TrafficDay, nil
+	}
+	mode, ok := trafficModeNames[s]
+	if !ok {
+		return "", fmt.Errorf("invalid mode %q: expected fiveminute, hour, day, month, year or top", s)
+	}
+	return mode, nil
+}
+
+func (m TrafficMode) entries(t *VnStatTraffic) *[]DayStats {
+	switch m {
+	case TrafficFiveMinute:
+		return &t.FiveMinute
+	case TrafficHour:
+		return &t.Hour
+	case TrafficDay:
+		return &t.Day
+	case TrafficMonth:
+		return &t.Month
+	case TrafficYear:
+		return &t.Year
+	case TrafficTop:
+		return &t.Top
+	}
+	return nil
+}
+
+type trafficBound struct {
+	time.Time
+	layout string
+}
+
+func (b trafficBound) arg() string {
+	return b.Format(b.layout)
+}
+
+type TrafficQuery struct {
+	Mode      TrafficMode
+	Limit     int
+	Begin     trafficBound
+	End       trafficBound
+	Interface string
+}
+
+var trafficDateLayouts = []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"}
+
+func parseTrafficBound(name, s string) (trafficBound, error) {
+	if s == "" {
+		return trafficBound{}, nil
+	}
+	for _, layout := range trafficDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			if layout == "2006-01-02T15:04" {
+				layout = "2006-01-02 15:04"
+			}
+			return trafficBound{Time: t, layout: layout}, nil
+		}
+	}
+	return trafficBound{}, fmt.Errorf("invalid %s %q: expected YYYY-MM-DD or YYYY-MM-DD HH:MM", name, s)
+}
+
+func ParseTrafficQuery(values url.Values) (*TrafficQuery, error) {
+	mode, err := ParseTrafficMode(values.Get("mode"))
+	if err != nil {
+		return nil, err
+	}
+
+	q := &TrafficQuery{
+		Mode:      mode,
+		Limit:     defaultTrafficLimit,
+		Interface: values.Get("interface"),
+	}
+
+	if s := values.Get("limit"); s != "" {
+		q.Limit, err = strconv.Atoi(s)
+		if err != nil || q.Limit < 0 || q.Limit > maxTrafficLimit {
+			return nil, fmt.Errorf("invalid limit %q: expected 0-%d", s, maxTrafficLimit)
+		}
+	}
+
+	if q.Begin, err = parseTrafficBound("start", values.Get("start")); err != nil {
+		return nil, err
+	}
+	if q.End, err = parseTrafficBound("end", values.Get("end")); err != nil {
+		return nil, err
+	}
+	if !q.Begin.IsZero() && !q.End.IsZero() && q.End.Before(q.Begin.Time) {
+		return nil, errors.New("end is before start")
+	}
+
+	return q, nil
+}
+
+func (q *TrafficQuery) vnStatArgs() []string {
+	args := []string{"--json", string(q.Mode), strconv.Itoa(q.Limit)}
+	if !q.Begin.IsZero() {
+		args = append(args, "--begin", q.Begin.arg())
+	}
+	if !q.End.IsZero() {
+		args = append(args, "--end", q.End.arg())
+	}
+	return args
+}
+
+// End dates without a time cover the whole day, as they do in vnstat.
+func (q *TrafficQuery) endExclusive() int64 {
+	if q.End.layout == "2006-01-02" {
+		return q.End.AddDate(0, 0, 1).Unix()
+	}
+	return q.End.Add(time.Minute).Unix()
+}
+
+func (q *TrafficQuery) filter(entries []DayStats) []DayStats {
+	result := make([]DayStats, 0, len(entries))
+	for _, entry := range entries {
+		if !q.Begin.IsZero() && entry.Timestamp < q.Begin.Unix() {
+			continue
+		}
+		if !q.End.IsZero() && entry.Timestamp >= q.endExclusive() {
+			continue
+		}
+		result = append(result, entry)
+	}
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[len(result)-q.Limit:]
+	}
+	for i := range result {
+		result[i].ID = i
+	}
+	return result
+}
+
+func decodeVnStatJSON(content []byte) (*VnStatData, error) {
+	var vnStat VnStatData
+	if err := json.Unmarshal(content, &vnStat); err != nil {
+		return nil, err
+	}
+	if vnStat.JsonVersion != "2" {
+		return nil, fmt.Errorf("unsupported vnstat json version %q", vnStat.JsonVersion)
+	}
+	return &vnStat, nil
+}
+
+func runVnStat(args ...string) ([]byte, error) {
+	out, err := exec.Command("vnstat", args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+			return nil, fmt.Errorf("vnstat: %s", msg)
+		}
+		return nil, fmt.Errorf("vnstat: %w", err)
+	}
+	return out, err
+}
+
+// readTrafficQuery uses vnstat when it is installed and falls back to the
+// built-in sampler only when it is not, so clients never get mixed sources.
+func readTrafficQuery(q *TrafficQuery) (*VnStatData, error) {
+	out, err := runVnStat(q.vnStatArgs()...)
+	if err == nil {
+		return decodeVnStatJSON(out)
+	}
+	if !errors.Is(err, exec.ErrNotFound) {
+		return nil, err
+	}
+	if trafficSampler == nil {
+		return nil, errors.New("vnstat is not installed and traffic sampler is not running")
+	}
+	if !isTrafficMode(string(q.Mode)) {
+		return nil, fmt.Errorf("mode %q requires vnstat", q.Mode)
+	}
+
+	data := trafficSampler.VnStatData(string(q.Mode), 0)
+	for i := range data.Interfaces {
+		entries := q.Mode.entries(&data.Interfaces[i].Traffic)
+		*entries = q.filter(*entries)
+	}
+	return data, nil
+}
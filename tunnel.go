@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const tunnelMaxBytes = 16 << 20
+
+type TunnelCheck struct {
+	OK          bool    `json:"ok"`
+	Target      string  `json:"target"`
+	Status      int     `json:"status,omitempty"`
+	TTFBMs      int64   `json:"ttfbMs"`
+	Bytes       int64   `json:"bytes"`
+	BytesPerSec float64 `json:"bytesPerSec"`
+	AuthFailed  bool    `json:"authFailed,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+func tunnelProxyURL(link *ParsedLink) *url.URL {
+	u := &url.URL{Scheme: link.Scheme, Host: link.Address()}
+	if link.Scheme == "socks" {
+		u.Scheme = "socks5"
+	}
+	if link.Username != "" || link.Password != "" {
+		u.User = url.UserPassword(link.Username, link.Password)
+	}
+	return u
+}
+
+func isProxyAuthError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "Proxy Authentication Required") ||
+		strings.Contains(msg, "username/password authentication failed") ||
+		strings.Contains(msg, "no acceptable authentication methods")
+}
+
+func testTunnel(ctx context.Context, link *ParsedLink, target string, timeout time.Duration) *TunnelCheck {
+	result := &TunnelCheck{Target: target}
+
+	transport := &http.Transport{
+		Proxy:             http.ProxyURL(tunnelProxyURL(link)),
+		DisableKeepAlives: true,
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: timeout}
+
+	var start, firstByte time.Time
+	trace := &httptrace.ClientTrace{
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, target, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	start = time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.AuthFailed = isProxyAuthError(err)
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	result.TTFBMs = firstByte.Sub(start).Milliseconds()
+
+	result.Bytes, err = io.Copy(io.Discard, io.LimitReader(resp.Body, tunnelMaxBytes))
+	if elapsed := time.Since(firstByte).Seconds(); elapsed > 0 {
+		result.BytesPerSec = math.Round(float64(result.Bytes) / elapsed)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusProxyAuthRequired:
+		result.AuthFailed = true
+		result.Error = resp.Status
+	case resp.StatusCode >= 400:
+		result.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	case err != nil:
+		result.Error = err.Error()
+	default:
+		result.OK = true
+	}
+
+	return result
+}
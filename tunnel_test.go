@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const tunnelTestBody = 64 << 10
+
+func newTunnelTarget(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, tunnelTestBody))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newHTTPProxy forwards plain HTTP requests and requires basic proxy auth.
+func newHTTPProxy(t *testing.T, user, pass string) *httptest.Server {
+	t.Helper()
+	want := "Basic " + basicAuth(user, pass)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != want {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		req, _ := http.NewRequestWithContext(r.Context(), r.Method, r.URL.String(), nil)
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func basicAuth(user, pass string) string {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth(user, pass)
+	_, auth, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	return auth
+}
+
+// newSocksProxy serves a minimal SOCKS5 CONNECT with username/password auth.
+func newSocksProxy(t *testing.T, user, pass string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSocks(conn, user, pass)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveSocks(conn net.Conn, user, pass string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return
+	}
+	io.CopyN(io.Discard, r, int64(head[1]))
+	conn.Write([]byte{5, 2})
+
+	readString := func() string {
+		n, _ := r.ReadByte()
+		b := make([]byte, n)
+		io.ReadFull(r, b)
+		return string(b)
+	}
+	r.ReadByte()
+	if readString() != user || readString() != pass {
+		conn.Write([]byte{1, 1})
+		return
+	}
+	conn.Write([]byte{1, 0})
+
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(r, req); err != nil || req[3] != 1 {
+		return
+	}
+	addr := make([]byte, 6)
+	io.ReadFull(r, addr)
+	target := net.JoinHostPort(net.IP(addr[:4]).String(), strconv.Itoa(int(binary.BigEndian.Uint16(addr[4:]))))
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(upstream, r)
+	io.Copy(conn, upstream)
+}
+
+func TestTunnelHTTP(t *testing.T) {
+	target := newTunnelTarget(t)
+	proxy := newHTTPProxy(t, "user", "pass")
+	host := strings.TrimPrefix(proxy.URL, "http://")
+
+	link, err := ParseHTTPLink("http://user:pass@" + host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := testTunnel(context.Background(), link, target.URL, 5*time.Second)
+	if !result.OK || result.Bytes != tunnelTestBody || result.BytesPerSec <= 0 {
+		t.Errorf("testTunnel() = %+v, want OK with %d bytes", result, tunnelTestBody)
+	}
+
+	link, _ = ParseHTTPLink("http://user:wrong@" + host)
+	result = testTunnel(context.Background(), link, target.URL, 5*time.Second)
+	if result.OK || !result.AuthFailed || result.Status != http.StatusProxyAuthRequired {
+		t.Errorf("testTunnel() with bad password = %+v, want auth failure", result)
+	}
+}
+
+func TestTunnelSocks(t *testing.T) {
+	target := newTunnelTarget(t)
+	addr := newSocksProxy(t, "user", "pass")
+
+	link, err := ParseSocksLink("socks://user:pass@" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := testTunnel(context.Background(), link, target.URL, 5*time.Second)
+	if !result.OK || result.Bytes != tunnelTestBody || result.BytesPerSec <= 0 {
+		t.Errorf("testTunnel() = %+v, want OK with %d bytes", result, tunnelTestBody)
+	}
+
+	link, _ = ParseSocksLink("socks://user:wrong@" + addr)
+	result = testTunnel(context.Background(), link, target.URL, 5*time.Second)
+	if result.OK || !result.AuthFailed {
+		t.Errorf("testTunnel() with bad password = %+v, want auth failure", result)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const uptimeRetention = 30 * 24 * time.Hour
+const uptimeRecentTransitions = 20
+
+var uptimeStore *UptimeStore
+
+type UptimeStore struct {
+	path    string
+	mu      sync.Mutex
+	servers map[string]*UptimeHistory
+}
+
+type UptimeHistory struct {
+	Transitions []UptimeTransition `json:"transitions"`
+	Buckets     []UptimeBucket     `json:"buckets"`
+}
+
+type UptimeTransition struct {
+	Timestamp int64  `json:"timestamp"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+type UptimeBucket struct {
+	Hour         int64 `json:"hour"`
+	Checks       int   `json:"checks"`
+	Up           int   `json:"up"`
+	LatencySumMs int64 `json:"latencySumMs"`
+}
+
+type UptimeWindows struct {
+	Day   *float64 `json:"24h"`
+	Week  *float64 `json:"7d"`
+	Month *float64 `json:"30d"`
+}
+
+type ServerUptime struct {
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	Location     string             `json:"location"`
+	ProviderName string             `json:"providerName"`
+	Plan         string             `json:"plan"`
+	Status       string             `json:"status"`
+	Availability UptimeWindows      `json:"availability"`
+	LatencyMs    UptimeWindows      `json:"latencyMs"`
+	Transitions  []UptimeTransition `json:"transitions"`
+}
+
+func NewUptimeStore(path string) (*UptimeStore, error) {
+	s := &UptimeStore{
+		path:    path,
+		servers: make(map[string]*UptimeHistory),
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &s.servers); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *UptimeStore) Record(health ServerHealth, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history, ok := s.servers[health.ID]
+	if !ok {
+		history = &UptimeHistory{}
+		s.servers[health.ID] = history
+	}
+
+	if n := len(history.Transitions); n == 0 || history.Transitions[n-1].Status != health.Status {
+		history.Transitions = append(history.Transitions, UptimeTransition{
+			Timestamp: health.CheckedAt,
+			Status:    health.Status,
+			Error:     health.LastError,
+		})
+	}
+
+	hour := health.CheckedAt - health.CheckedAt%3600
+	if n := len(history.Buckets); n == 0 || history.Buckets[n-1].Hour != hour {
+		history.Buckets = append(history.Buckets, UptimeBucket{Hour: hour})
+	}
+	bucket := &history.Buckets[len(history.Buckets)-1]
+	bucket.Checks++
+	if health.Status == HealthUp {
+		bucket.Up++
+		bucket.LatencySumMs += latency.Milliseconds()
+	}
+}
+
+func (s *UptimeStore) Prune(active []*ProxyServerInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make(map[string]bool, len(active))
+	for _, server := range active {
+		ids[server.ID] = true
+	}
+	cutoff := time.Now().Add(-uptimeRetention).Unix()
+
+	for id, history := range s.servers {
+		if !ids[id] {
+			delete(s.servers, id)
+			continue
+		}
+		i := 0
+		for i < len(history.Buckets) && history.Buckets[i].Hour < cutoff {
+			i++
+		}
+		history.Buckets = history.Buckets[i:]
+		i = 0
+		for i+1 < len(history.Transitions) && history.Transitions[i+1].Timestamp < cutoff {
+			i++
+		}
+		history.Transitions = history.Transitions[i:]
+	}
+}
+
+func (s *UptimeStore) Save() error {
+	s.mu.Lock()
+	content, err := json.Marshal(s.servers)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, content, 0644)
+}
+
+func (s *UptimeStore) Summary(server *ProxyServerInfo) ServerUptime {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := ServerUptime{
+		ID:           server.ID,
+		Name:         server.Name,
+		Location:     server.Location,
+		ProviderName: server.ProviderName,
+		Plan:         server.Plan,
+		Status:       HealthUnknown,
+		Transitions:  []UptimeTransition{},
+	}
+
+	history, ok := s.servers[server.ID]
+	if !ok {
+		return result
+	}
+
+	if n := len(history.Transitions); n > 0 {
+		result.Status = history.Transitions[n-1].Status
+		result.Transitions = append(result.Transitions, history.Transitions[max(0, n-uptimeRecentTransitions):]...)
+	}
+
+	now := time.Now()
+	result.Availability.Day, result.LatencyMs.Day = uptimeWindow(history.Buckets, now.Add(-24*time.Hour))
+	result.Availability.Week, result.LatencyMs.Week = uptimeWindow(history.Buckets, now.Add(-7*24*time.Hour))
+	result.Availability.Month, result.LatencyMs.Month = uptimeWindow(history.Buckets, now.Add(-uptimeRetention))
+
+	return result
+}
+
+func uptimeWindow(buckets []UptimeBucket, since time.Time) (*float64, *float64) {
+	var checks, up int
+	var latencySum int64
+	for _, b := range buckets {
+		if b.Hour+3600 <= since.Unix() {
+			continue
+		}
+		checks += b.Checks
+		up += b.Up
+		latencySum += b.LatencySumMs
+	}
+
+	if checks == 0 {
+		return nil, nil
+	}
+	availability := math.Round(float64(up)/float64(checks)*100000) / 1000
+	if up == 0 {
+		return &availability, nil
+	}
+	latency := math.Round(float64(latencySum)/float64(up)*10) / 10
+	return &availability, &latency
+}
+
+func uptimeHandle(w http.ResponseWriter, r *http.Request) {
+	if id := r.URL.Query().Get("id"); id != "" {
+		server := serverRegistry.Find(id)
+		if server == nil {
+			http.Error(w, errServerNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, uptimeStore.Summary(server))
+		return
+	}
+
+	servers := serverRegistry.Servers()
+	result := make([]ServerUptime, 0, len(servers))
+	for _, server := range servers {
+		result = append(result, uptimeStore.Summary(server))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
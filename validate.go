@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+type ValidationError struct {
+	Line int
+	Path string
+	Msg  string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Path, e.Msg)
+}
+
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+type serverValidator struct {
+	content []byte
+	offsets map[string]int64
+	errs    ValidationErrors
+}
+
+func ValidateProxyServers(content []byte) ([]*ProxyServerInfo, error) {
+	v := &serverValidator{content: content}
+
+	var servers []*ProxyServerInfo
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&servers); err != nil {
+		return nil, v.decodeError(err, dec.InputOffset())
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, ValidationErrors{{Line: lineAt(content, dec.InputOffset()), Msg: "unexpected data after top-level array"}}
+	}
+
+	v.offsets = indexJSONPaths(content)
+
+	ids := make(map[string]string, len(servers))
+	for i, s := range servers {
+		path := fmt.Sprintf("[%d]", i)
+		if s == nil {
+			v.add(path, "entry must be an object")
+			continue
+		}
+
+		v.required(path+".id", s.ID)
+		v.required(path+".name", s.Name)
+		v.required(path+".infoLink", s.InfoLink)
+
+		if s.ID != "" {
+			if first, ok := ids[s.ID]; ok {
+				v.add(path+".id", fmt.Sprintf("duplicate id %q (first used at %s)", s.ID, first))
+			} else {
+				ids[s.ID] = path
+			}
+		}
+
+		if s.InfoLink != "" {
+			v.check(path+".infoLink", validateHTTPURL(s.InfoLink))
+		}
+		if s.ProviderLink != "" {
+			v.check(path+".providerLink", validateHTTPURL(s.ProviderLink))
+		}
+		if s.Limit != nil {
+			v.check(path+".limit", s.Limit.Validate())
+		}
+
+		s.ProxyLinks.Each(func(protocol *ProxyProtocol, index int, raw string) {
+			_, err := protocol.Parse(raw)
+			v.check(fmt.Sprintf("%s.proxyLinks.%s[%d]", path, protocol.Key, index), err)
+		})
+	}
+
+	if len(v.errs) > 0 {
+		return nil, v.errs
+	}
+
+	return servers, nil
+}
+
+func (v *serverValidator) add(path, msg string) {
+	line := 1
+	for p := path; p != ""; p = parentJSONPath(p) {
+		if offset, ok := v.offsets[p]; ok {
+			line = lineAt(v.content, offset)
+			break
+		}
+	}
+	v.errs = append(v.errs, ValidationError{Line: line, Path: path, Msg: msg})
+}
+
+func (v *serverValidator) check(path string, err error) {
+	if err != nil {
+		v.add(path, err.Error())
+	}
+}
+
+func (v *serverValidator) required(path, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(path, "is required")
+	}
+}
+
+func (v *serverValidator) decodeError(err error, offset int64) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	// Syntax and type error offsets point just past the offending token.
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = max(syntaxErr.Offset-1, 0)
+	case errors.As(err, &typeErr):
+		offset = max(typeErr.Offset-1, 0)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		offset = firstFieldOffset(indexJSONPaths(v.content), field, offset)
+	}
+	return ValidationErrors{{Line: lineAt(v.content, offset), Msg: err.Error()}}
+}
+
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("must be an absolute http(s) URL")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	return nil
+}
+
+func indexJSONPaths(content []byte) map[string]int64 {
+	offsets := make(map[string]int64)
+	dec := json.NewDecoder(bytes.NewReader(content))
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if _, ok := offsets[path]; !ok {
+			offsets[path] = offset
+		}
+
+		switch tok {
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		case json.Delim('{'):
+			for dec.More() {
+				keyOffset := dec.InputOffset()
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				keyPath := path + "." + fmt.Sprint(key)
+				offsets[keyPath] = keyOffset
+				if err := walk(keyPath); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err
+	}
+	walk("")
+
+	return offsets
+}
+
+func firstFieldOffset(offsets map[string]int64, field string, fallback int64) int64 {
+	result := fallback
+	for path, offset := range offsets {
+		if strings.HasSuffix(path, "."+field) && offset < result {
+			result = offset
+		}
+	}
+	return result
+}
+
+func parentJSONPath(path string) string {
+	i := strings.LastIndexAny(path, ".[")
+	if i <= 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+func lineAt(content []byte, offset int64) int {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	for offset < int64(len(content)) && strings.IndexByte(" \t\r\n,:", content[offset]) >= 0 {
+		offset++
+	}
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
+
+func runServersCommand(args []string) int {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprintln(os.Stderr, "usage: proxyhub servers validate [file]")
+		return 2
+	}
+
+	path := proxyServersFile
+	if len(args) > 1 {
+		path = args[1]
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		return 1
+	}
+
+	servers, err := ValidateProxyServers(content)
+	if err != nil {
+		var errs ValidationErrors
+		if errors.As(err, &errs) {
+			for _, e := range errs {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", path, e)
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		}
+		return 1
+	}
+
+	fmt.Printf("%s: %d servers OK\n", path, len(servers))
+	return 0
+}
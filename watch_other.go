@@ -0,0 +1,35 @@
+//go:build !linux
+
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+func watchFile(ctx context.Context, path string, changes chan<- struct{}) error {
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if !info.ModTime().Equal(lastMod) {
+				lastMod = info.ModTime()
+				notifyChange(changes)
+			}
+		}
+	}
+}